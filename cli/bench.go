@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newBenchCommand() *cobra.Command {
+	var sel cmd.RequestSelector
+	var opts cmd.BenchOptions
+	c := &cobra.Command{
+		Use:   "bench <file.http>",
+		Short: "Load test a request of a .http file",
+		Long: `Send a request of a .http file many times with a pool of concurrent workers
+and report the throughput, latency percentiles, status codes and errors.
+The test stops after -n requests or after --duration, whichever comes first.
+Ctrl+c stops it early and still prints the report.`,
+		Example: `  postbear bench api.http --name "list users" -c 20 -n 5000
+  postbear bench api.http --index 1 -c 5 --duration 30s --rate 100`,
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if (sel.Name == "") == !c.Flags().Changed("index") {
+				return usageError{fmt.Errorf("exactly one of --name or --index is required")}
+			}
+			if c.Flags().Changed("index") && sel.Index < 1 {
+				return usageError{fmt.Errorf("--index starts at 1")}
+			}
+			if err := checkOutput(); err != nil {
+				return err
+			}
+			if c.Flags().Changed("duration") && !c.Flags().Changed("requests") {
+				opts.Requests = 0
+			}
+			opts.Timeout = timeout
+			bench, err := cmd.BenchFromFile(args[0], sel, envName, opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			done := make(chan struct{})
+			go func() {
+				bench.Run(ctx)
+				close(done)
+			}()
+			// Show the progress on stderr while waiting
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+		wait:
+			for {
+				select {
+				case <-done:
+					break wait
+				case <-ticker.C:
+					if verbose {
+						r := bench.Report()
+						fmt.Fprintf(os.Stderr, "%d sent, %d errors, %.1f req/s\n", r.Sent, r.Errors, r.Throughput)
+					}
+				}
+			}
+
+			report := bench.Report()
+			if output == cmd.OutputJSON {
+				printBenchJSON(report)
+				return nil
+			}
+			fmt.Print(report.String())
+			fmt.Println("Latency histogram:")
+			fmt.Print(report.RenderHistogram(10, 40))
+			return nil
+		},
+	}
+	c.Flags().StringVar(&sel.Name, "name", "", "name of the request to benchmark")
+	c.Flags().IntVarP(&sel.Index, "index", "i", 0, "position of the request to benchmark, starting at 1")
+	c.Flags().IntVarP(&opts.Concurrency, "concurrency", "c", 10, "number of concurrent workers")
+	c.Flags().IntVarP(&opts.Requests, "requests", "n", 200, "number of requests to send, 0 for no limit with --duration")
+	c.Flags().DurationVar(&opts.Duration, "duration", 0, "stop after this long, e.g. 30s")
+	c.Flags().Float64Var(&opts.Rate, "rate", 0, "requests per second over all workers, 0 for no limit")
+	c.RegisterFlagCompletionFunc("name", requestNames)
+	return c
+}
+
+func printBenchJSON(r cmd.BenchReport) {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	statuses := map[string]int{}
+	for code, count := range r.Statuses {
+		statuses[fmt.Sprint(code)] = count
+	}
+	out := map[string]interface{}{
+		"requests":   r.Sent,
+		"errors":     r.Errors,
+		"elapsedMs":  ms(r.Elapsed),
+		"throughput": r.Throughput,
+		"latencyMs": map[string]float64{
+			"min":  ms(r.Min),
+			"mean": ms(r.Mean),
+			"p50":  ms(r.P50),
+			"p90":  ms(r.P90),
+			"p99":  ms(r.P99),
+			"max":  ms(r.Max),
+		},
+		"statuses":   statuses,
+		"errorKinds": r.ErrorKinds,
+	}
+	b, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(b))
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newDiffCommand() *cobra.Command {
+	var sel cmd.RequestSelector
+	var against string
+	var ignore []string
+	c := &cobra.Command{
+		Use:   "diff <file.http>",
+		Short: "Compare the responses of a request in two environments",
+		Long: `Send a request of a .http file with --env and with --against, then show the
+status, header and JSON field differences between both responses. Fields
+listed in the diffIgnore global variable of the file, or with --ignore, are
+skipped: a name like updatedAt matches the field anywhere, a path like
+data.*.id matches that path only. Exits with 1 when the responses differ.`,
+		Example: `  postbear diff api.http --name "list users" --env staging --against prod
+  postbear diff api.http --index 2 --env staging --against prod --ignore id --ignore createdAt`,
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if (sel.Name == "") == !c.Flags().Changed("index") {
+				return usageError{fmt.Errorf("exactly one of --name or --index is required")}
+			}
+			if !c.Flags().Changed("against") {
+				return usageError{fmt.Errorf("--against is required")}
+			}
+			if err := checkOutput(); err != nil {
+				return err
+			}
+			diff, err := cmd.DiffEnvironments(args[0], sel, envName, against, ignore, timeout)
+			if err != nil {
+				return err
+			}
+			if output == cmd.OutputJSON {
+				printDiffJSON(diff)
+			} else {
+				fmt.Print(diff.String())
+			}
+			if !diff.Empty() {
+				// Exit with 1 like diff(1)
+				return silentError{exitFailure}
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVar(&sel.Name, "name", "", "name of the request to compare")
+	c.Flags().IntVarP(&sel.Index, "index", "i", 0, "position of the request to compare, starting at 1")
+	c.Flags().StringVar(&against, "against", "", "environment to compare --env with")
+	c.Flags().StringArrayVar(&ignore, "ignore", nil, "field name or path to ignore, can be repeated")
+	c.RegisterFlagCompletionFunc("name", requestNames)
+	c.RegisterFlagCompletionFunc("against", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cmd.EnvironmentNames(args[0]), cobra.ShellCompDirectiveNoFileComp
+	})
+	return c
+}
+
+func printDiffJSON(d cmd.ResponseDiff) {
+	type status struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	out := map[string]interface{}{
+		"same":    d.Empty(),
+		"status":  status{From: d.From.Status, To: d.To.Status},
+		"headers": append([]cmd.DiffEntry{}, d.Headers...),
+		"body":    append([]cmd.DiffEntry{}, d.Body...),
+	}
+	if d.Text != nil {
+		out["text"] = d.Text
+	}
+	b, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(b))
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newEnvCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "env",
+		Short: "Show and edit the variables of a .http file",
+	}
+
+	list := &cobra.Command{
+		Use:               "list <file.http>",
+		Short:             "List the variables with their sources, and the available environments",
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			traces, err := cmd.TraceVariables(args[0], envName)
+			if err != nil {
+				return err
+			}
+			for _, t := range traces {
+				source := t.Source
+				if len(t.Overridden) > 0 {
+					source += ", overrides " + strings.Join(t.Overridden, ", ")
+				}
+				fmt.Printf("%s = %s  (%s)\n", t.Name, cmd.MaskSecrets(t.Value), source)
+			}
+			if envs := cmd.EnvironmentNames(args[0]); len(envs) > 0 {
+				fmt.Println("\nEnvironments:")
+				for _, env := range envs {
+					fmt.Println("  " + env)
+				}
+			}
+			return nil
+		},
+	}
+
+	var secret bool
+	set := &cobra.Command{
+		Use:   "set <file.http> <name> <value>",
+		Short: "Set a global variable",
+		Long: `Set a global variable. With --secret the value goes to the OS keyring, or to
+a file encrypted with POSTBEAR_SECRET_PASSPHRASE where there is none, and the
+file only keeps a {{$secret name}} reference. The value is read from stdin
+when it is left out, so it stays out of the shell history.`,
+		Example: `  postbear env set api.http baseUrl https://api.example.com
+  postbear env set api.http token --secret < token.txt`,
+		Args:              usageArgs(cobra.RangeArgs(2, 3)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if !secret {
+				if len(args) != 3 {
+					return usageError{fmt.Errorf("the value is required, only --secret reads it from stdin")}
+				}
+				return cmd.SetGlobalVar(args[0], args[1], &args[2])
+			}
+			value := ""
+			if len(args) == 3 {
+				value = args[2]
+			} else {
+				in, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				value = strings.TrimRight(string(in), "\r\n")
+			}
+			store, err := cmd.SetSecretVar(args[0], args[1], value)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s stored in %s\n", args[1], store.Name())
+			return nil
+		},
+	}
+	set.Flags().BoolVar(&secret, "secret", false, "keep the value in the secret store instead of the file")
+
+	unset := &cobra.Command{
+		Use:               "unset <file.http> <name>",
+		Short:             "Remove a global variable, and its secret",
+		Args:              usageArgs(cobra.ExactArgs(2)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if cmd.LoadGlobalVarsFromHTTPFile(args[0])[args[1]] == cmd.SecretReference(args[1]) {
+				if err := cmd.DeleteSecret(args[1]); err != nil && !errors.Is(err, cmd.ErrSecretNotFound) {
+					return err
+				}
+			}
+			return cmd.SetGlobalVar(args[0], args[1], nil)
+		},
+	}
+
+	c.AddCommand(list, set, unset)
+	return c
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newGenerateCommand() *cobra.Command {
+	var dir string
+	var sync bool
+	c := &cobra.Command{
+		Use:   "generate <openapi.yaml>",
+		Short: "Generate a .http workspace from an OpenAPI spec",
+		Long: `Generate a .http file per tag of an OpenAPI 3 spec, with a request for every
+operation. Path parameters become {{variables}}, request bodies are filled
+with the examples of the spec or synthesised from their schemas, and the
+security schemes are sent as {{token}}, {{apiKey}} or {{basicAuth}} headers.
+
+The servers of the spec become the environments of http-client.env.json, and
+the credentials are left empty in http-client.private.env.json. Existing
+environment values are never changed.
+
+--sync updates files generated before: the requests still as generated follow
+the spec, the ones edited by hand are kept, and new operations are added.`,
+		Example: `  postbear generate openapi.yaml --dir api
+  postbear generate openapi.yaml --dir api --sync`,
+		Args: usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"yaml", "yml", "json"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			spec, err := cmd.LoadOpenAPI(args[0])
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			specRef, err := specReference(dir, args[0])
+			if err != nil {
+				return err
+			}
+			files := cmd.GenerateFromOpenAPI(spec, specRef)
+			if len(files) == 0 {
+				return fmt.Errorf("%s has no operations", args[0])
+			}
+
+			// Refuse before writing anything
+			if !sync {
+				for _, f := range files {
+					if _, err := os.Stat(filepath.Join(dir, f.Name)); err == nil {
+						return usageError{fmt.Errorf("%s already exists, use --sync to update it", filepath.Join(dir, f.Name))}
+					}
+				}
+			}
+
+			for _, f := range files {
+				path := filepath.Join(dir, f.Name)
+				data := f.Data
+				summary := cmd.SyncStats{Added: len(data.Requests)}.String()
+				if sync {
+					existing, err := cmd.LoadHTTPFile(path)
+					switch {
+					case errors.Is(err, fs.ErrNotExist):
+					case err != nil:
+						return err
+					default:
+						var stats cmd.SyncStats
+						data, stats = cmd.SyncGenerated(existing, data)
+						summary = stats.String()
+					}
+				}
+				if err := cmd.SaveHTTPFile(data, path); err != nil {
+					return err
+				}
+				fmt.Printf("%s: %s\n", path, summary)
+			}
+
+			shared, private := spec.GenerateEnvironments()
+			if err := cmd.MergeEnvironmentFile(dir, false, shared); err != nil {
+				return err
+			}
+			if err := cmd.MergeEnvironmentFile(dir, true, private); err != nil {
+				return err
+			}
+			fmt.Printf("\nOpen it with: postbear open %s\n", dir)
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&dir, "dir", "d", ".", "directory the .http files are written to")
+	c.Flags().BoolVar(&sync, "sync", false, "update previously generated files, keeping the requests edited by hand")
+	return c
+}
+
+// specReference is the path of the spec as seen from the generated files
+func specReference(dir, spec string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absSpec, err := filepath.Abs(spec)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absSpec)
+	if err != nil {
+		return absSpec, nil
+	}
+	return filepath.ToSlash(rel), nil
+}
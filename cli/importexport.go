@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newImportCommand() *cobra.Command {
+	var file, name string
+	c := &cobra.Command{
+		Use:   "import <curl command>",
+		Short: "Add a request to a .http file from a curl command",
+		Example: `  postbear import "curl -X POST https://fooapi.com/api/todos -H 'Content-Type: application/json' -d '{\"todo\":\"x\"}'"
+  pbpaste | postbear import - --file api.http`,
+		Args: usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(c *cobra.Command, args []string) error {
+			command := strings.Join(args, " ")
+			if command == "-" {
+				b, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					return err
+				}
+				command = string(b)
+			}
+			req, err := cmd.ParseCurl(command)
+			if err != nil {
+				return usageError{err}
+			}
+			if name != "" {
+				req.Name = name
+			}
+			if err := cmd.AppendRequest(file, req); err != nil {
+				return err
+			}
+			fmt.Printf("Imported %q into %s\n", req.Name, file)
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&file, "file", "f", "postbear.http", ".http file to add the request to")
+	c.Flags().StringVarP(&name, "name", "n", "", "name of the imported request")
+	return c
+}
+
+func newExportCommand() *cobra.Command {
+	var name string
+	c := &cobra.Command{
+		Use:               "export <file.http>",
+		Short:             "Print the requests of a .http file as curl commands",
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			data, err := cmd.LoadHTTPFile(args[0])
+			if err != nil {
+				return err
+			}
+			vars, err := cmd.ResolveVariables(args[0], envName)
+			if err != nil {
+				return err
+			}
+			requests, err := cmd.SelectRequests(data, cmd.RequestSelector{Name: name})
+			if err != nil {
+				return err
+			}
+			for i, req := range requests {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Println("# " + req.Name)
+				fmt.Println(cmd.MaskSecrets(cmd.ToCurl(cmd.ResolveRequest(req, vars))))
+			}
+			return nil
+		},
+	}
+	c.Flags().StringVarP(&name, "name", "n", "", "only export the request with this name")
+	c.RegisterFlagCompletionFunc("name", requestNames)
+	return c
+}
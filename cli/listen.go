@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/carban/postbear/cmd"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+func newListenCommand() *cobra.Command {
+	var flags serveFlags
+	var file string
+	inspector := &cmd.Inspector{}
+	c := &cobra.Command{
+		Use:   "listen",
+		Short: "Capture the requests sent to a local port, e.g. webhooks",
+		Long: `Accept any request on a local port and show its method, path, headers and
+body as it arrives. Every request gets the --status code and a JSON echo of
+what was received. In the TUI, s saves the selected request in --file so it can
+be replayed.`,
+		Example: `  postbear listen --port 9000 --file webhooks.http
+  postbear listen --status 202 --no-tui`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(c *cobra.Command, args []string) error {
+			if inspector.Status < 100 || inspector.Status > 599 {
+				return usageError{fmt.Errorf("invalid status %d", inspector.Status)}
+			}
+			return flags.serve(inspector,
+				func(address string) tea.Model {
+					return cmd.NewListenView(inspector, address, file)
+				},
+				func() {
+					inspector.OnRequest(func(r cmd.CapturedRequest) {
+						fmt.Printf("%s %s %s %s\n", r.Time.Format("15:04:05"), r.Method, r.Path, r.Proto)
+						for _, line := range headerLines(r) {
+							fmt.Println(line)
+						}
+						if r.Body != "" {
+							fmt.Println()
+							fmt.Println(r.Body)
+						}
+						fmt.Println()
+					})
+				})
+		},
+	}
+	flags.add(c, 9000)
+	c.Flags().StringVarP(&file, "file", "f", "postbear.http", ".http file captured requests are saved to")
+	c.Flags().IntVar(&inspector.Status, "status", 200, "status code answered to every request")
+	return c
+}
+
+func headerLines(r cmd.CapturedRequest) []string {
+	var lines []string
+	for key, values := range r.Headers {
+		lines = append(lines, key+": "+strings.Join(values, ", "))
+	}
+	sort.Strings(lines)
+	return lines
+}
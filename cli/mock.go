@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/carban/postbear/cmd"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// serveFlags are the flags of the commands running a local server
+type serveFlags struct {
+	host  string
+	port  int
+	noTUI bool
+}
+
+func (f *serveFlags) add(c *cobra.Command, port int) {
+	c.Flags().StringVar(&f.host, "host", "127.0.0.1", "address to listen on, 0.0.0.0 for every interface")
+	c.Flags().IntVarP(&f.port, "port", "p", port, "port to listen on")
+	c.Flags().BoolVar(&f.noTUI, "no-tui", false, "print the requests as log lines instead of opening the TUI")
+}
+
+// serve runs handler until the TUI quits, or until ctrl+c in log mode
+func (f *serveFlags) serve(handler http.Handler, view func(address string) tea.Model, logMode func()) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(f.host, strconv.Itoa(f.port)))
+	if err != nil {
+		return err
+	}
+	address := "http://" + listener.Addr().String()
+	server := &http.Server{Handler: handler}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	defer server.Shutdown(context.Background())
+
+	if f.noTUI || !isatty.IsTerminal(os.Stdout.Fd()) {
+		logMode()
+		fmt.Fprintf(os.Stderr, "Listening on %s, ctrl+c to stop\n", address)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-served:
+			return err
+		}
+	}
+
+	_, err = tea.NewProgram(view(address), tea.WithAltScreen()).Run()
+	if err == nil {
+		select {
+		case err = <-served:
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+		default:
+		}
+	}
+	return err
+}
+
+func newMockCommand() *cobra.Command {
+	var flags serveFlags
+	c := &cobra.Command{
+		Use:   "mock <file.http>",
+		Short: "Serve the example responses of a .http file",
+		Long: `Serve every request of a .http file on its method and path, answering with
+the example response written after the request:
+
+  ### get user
+  GET {{host}}/users/{id}
+
+  HTTP/1.1 200 OK
+  # @delay 300ms
+  Content-Type: application/json
+
+  {"id": "{{id}}", "name": "Ann"}
+
+Path segments like {id} or :id match any value, which the response can use as
+{{id}}. Requests without an example response answer 204 No Content.`,
+		Example:           `  postbear mock api.http --port 8080`,
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			server, err := cmd.NewMockServer(args[0], envName)
+			if err != nil {
+				return err
+			}
+			return flags.serve(server,
+				func(address string) tea.Model {
+					return cmd.NewMockView(server, address)
+				},
+				func() {
+					for _, route := range server.Routes() {
+						fmt.Fprintln(os.Stderr, "  "+route)
+					}
+					server.OnRequest(func(e cmd.MockLogEntry) {
+						route := e.Route
+						if route == "" {
+							route = "-"
+						}
+						fmt.Printf("%s %d %s %s %s %vms\n", e.Time.Format("15:04:05"), e.Status, e.Method, e.Path, route, e.Duration.Milliseconds())
+					})
+				})
+		},
+	}
+	flags.add(c, 8080)
+	return c
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newReadCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "read <file.http>",
+		Short: "Open a .http file in the TUI",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"http", "rest"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			return runTUI(args[0])
+		},
+	}
+}
+
+func newOpenCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "open <directory>",
+		Short: "Open every .http file of a directory in the TUI",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			return runTUI(args[0])
+		},
+	}
+}
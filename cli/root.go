@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/carban/postbear/cmd"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the postbear command
+const (
+	exitOK      = 0
+	exitFailure = 1 // a test failed or something else went wrong
+	exitUsage   = 2 // wrong command line, or a request that cannot be sent
+	exitNetwork = 3 // the request got no response
+	exitStatus  = 4 // 4xx/5xx response with --fail
+)
+
+// usageError marks errors caused by a wrong command line
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+// silentError sets the exit code of a command that already told why it failed
+type silentError struct {
+	code int
+}
+
+func (e silentError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// usageArgs reports argument validation errors as usage errors
+func usageArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(c *cobra.Command, args []string) error {
+		if err := validate(c, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+// Flags shared by every command
+var (
+	envName  string
+	timeout  time.Duration
+	verbose  bool
+	output   string
+	fail     bool
+	noColor  bool
+	autosave bool
+)
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "postbear",
+		Short: "A lightweight API client for the terminal",
+		Long: `Postbear is a lightweight API client for the terminal.
+
+Run it without arguments to open the TUI, in a directory with .http files
+it opens them all as a workspace.`,
+		Args:         usageArgs(cobra.NoArgs),
+		SilenceUsage: true,
+		PersistentPreRun: func(c *cobra.Command, args []string) {
+			if noColor || !cmd.ColorEnabled() {
+				cmd.DisableColor()
+			}
+		},
+		SilenceErrors: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			// Open the current directory as a workspace when it has .http files
+			if files, err := cmd.DiscoverHTTPFiles("."); err == nil && len(files) > 0 {
+				return runTUI(".")
+			}
+			return runTUI("")
+		},
+	}
+	root.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return usageError{err}
+	})
+
+	root.PersistentFlags().StringVarP(&envName, "env", "e", "", "environment of http-client.env.json used to resolve {{variables}}")
+	root.PersistentFlags().DurationVar(&timeout, "timeout", 30*time.Second, "request timeout, 0 for none")
+	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "print the request before sending it")
+	root.PersistentFlags().StringVarP(&output, "output", "o", cmd.OutputPretty, "output format: pretty, json or raw")
+	root.PersistentFlags().BoolVar(&fail, "fail", false, "exit with code 4 on 4xx/5xx responses")
+	root.PersistentFlags().BoolVar(&noColor, "no-color", false, "print without colors, also set by NO_COLOR or when not writing to a terminal")
+	root.PersistentFlags().BoolVar(&autosave, "autosave", cmd.AutosaveFromEnv(), "save changes automatically in the TUI")
+	root.RegisterFlagCompletionFunc("output", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{cmd.OutputPretty, cmd.OutputJSON, cmd.OutputRaw}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	root.AddCommand(
+		newRunCommand(),
+		newReadCommand(),
+		newOpenCommand(),
+		newSendCommand(),
+		newTestCommand(),
+		newBenchCommand(),
+		newMockCommand(),
+		newListenCommand(),
+		newDiffCommand(),
+		newGenerateCommand(),
+		newImportCommand(),
+		newExportCommand(),
+		newEnvCommand(),
+		newVersionCommand(),
+	)
+	return root
+}
+
+// checkOutput validates the --output flag
+func checkOutput() error {
+	switch output {
+	case cmd.OutputPretty, cmd.OutputJSON, cmd.OutputRaw:
+		return nil
+	}
+	return usageError{fmt.Errorf("invalid output %q, expected pretty, json or raw", output)}
+}
+
+func runTUI(path string) error {
+	// The terminal belongs to the TUI, alt+u asks for the passphrase
+	cmd.PassphrasePrompt = nil
+	p := tea.NewProgram(cmd.NewModel(path).WithEnvironment(envName).WithAutosave(autosave),
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+	_, err := p.Run()
+	return err
+}
+
+// promptPassphrase asks for the passphrase of the secrets file on the
+// terminal, stdin may hold the value of a secret. Without a terminal there
+// is no passphrase.
+func promptPassphrase() (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", nil
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, "Passphrase of the secrets file: ")
+	value, err := term.ReadPassword(tty.Fd())
+	fmt.Fprintln(tty)
+	return string(value), err
+}
+
+// Execute runs the command line and returns the process exit code
+func Execute() int {
+	cmd.PassphrasePrompt = promptPassphrase
+	err := newRootCommand().Execute()
+	if err == nil {
+		return exitOK
+	}
+	var silent silentError
+	if errors.As(err, &silent) {
+		return silent.code
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	var usage usageError
+	var invalid cmd.InvalidRequestError
+	var network cmd.NetworkError
+	var status cmd.StatusError
+	switch {
+	case errors.As(err, &usage), errors.As(err, &invalid):
+		fmt.Fprintln(os.Stderr, "Run 'postbear --help' for usage.")
+		return exitUsage
+	case errors.As(err, &network):
+		return exitNetwork
+	case errors.As(err, &status):
+		return exitStatus
+	}
+	return exitFailure
+}
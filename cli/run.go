@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// Flags of the commands that send a request
+var (
+	headers []string
+	data    string
+	simple  bool
+)
+
+func addRequestFlags(c *cobra.Command) {
+	c.Flags().StringArrayVarP(&headers, "header", "H", nil, "request header as 'Name: value', can be repeated")
+	c.Flags().StringVarP(&data, "data", "d", "", "request body, @file reads it from a file and @- from stdin")
+	c.Flags().BoolVarP(&simple, "simple", "s", false, "print only the response body")
+}
+
+// readData resolves the -d flag, reading @file references and @- from stdin
+func readData(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	var content []byte
+	var err error
+	if value == "@-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(value[1:])
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading body: %w", err)
+	}
+	return string(content), nil
+}
+
+// pipedStdin reports whether something is piped or redirected into stdin
+func pipedStdin() bool {
+	fd := os.Stdin.Fd()
+	return !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd)
+}
+
+// cliOptions builds the request options from the flags
+func cliOptions() (cmd.CLIOptions, error) {
+	if err := checkOutput(); err != nil {
+		return cmd.CLIOptions{}, err
+	}
+	for _, h := range headers {
+		if _, _, err := cmd.ParseHeaderLine(h); err != nil {
+			return cmd.CLIOptions{}, usageError{err}
+		}
+	}
+	body, err := readData(data)
+	if err != nil {
+		return cmd.CLIOptions{}, err
+	}
+	return cmd.CLIOptions{
+		Headers: headers,
+		Body:    body,
+		Timeout: timeout,
+		Verbose: verbose,
+		Output:  output,
+		Simple:  simple,
+		Fail:    fail,
+	}, nil
+}
+
+func newRunCommand() *cobra.Command {
+	var ignoreStdin bool
+	c := &cobra.Command{
+		Use:   "run <method> <url> [payload | request items...]",
+		Short: "Send a request",
+		Long: `Send a request. The body is the payload argument, the -d flag, or what is
+piped into stdin. It can also be built from HTTPie style request items:
+
+  name=value    string field of a JSON body
+  name:=json    raw JSON field of a JSON body, e.g. done:=true or tags:='["a"]'
+  name==value   query parameter
+  Name:value    header`,
+		Example: `  postbear run GET https://fooapi.com/api/users
+  postbear run POST https://fooapi.com/api/todos -H 'Authorization: Bearer xyz' -d @todo.json
+  cat todo.json | postbear run POST https://fooapi.com/api/todos
+  postbear run POST https://fooapi.com/api/todos todo='Buy milk' done:=false Authorization:'Bearer xyz'
+  postbear run GET https://fooapi.com/api/users page==2 -s`,
+		Args: usageArgs(cobra.MinimumNArgs(2)),
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			opts, err := cliOptions()
+			if err != nil {
+				return err
+			}
+			url, items := args[1], args[2:]
+
+			// A JSON payload can still be given as the last argument
+			var payload string
+			if len(items) == 1 && looksLikePayload(items[0]) {
+				payload, items = items[0], nil
+			}
+			short, err := cmd.ParseShorthand(items)
+			if err != nil {
+				return usageError{err}
+			}
+			url, err = cmd.AddQuery(url, short.Query)
+			if err != nil {
+				return usageError{err}
+			}
+			opts.Headers = append(short.Headers, opts.Headers...)
+
+			bodies := 0
+			for _, body := range []string{payload, opts.Body, short.Body()} {
+				if body != "" {
+					opts.Body = body
+					bodies++
+				}
+			}
+			if bodies > 1 {
+				return usageError{fmt.Errorf("give the body only one way: payload argument, -d or request items")}
+			}
+			if bodies == 0 && !ignoreStdin && data == "" && pipedStdin() {
+				if opts.Body, err = readData("@-"); err != nil {
+					return err
+				}
+			}
+			return cmd.SendByCLI(strings.ToUpper(args[0]), url, opts)
+		},
+	}
+	addRequestFlags(c)
+	c.Flags().BoolVar(&ignoreStdin, "ignore-stdin", false, "don't read the body from stdin when it is piped")
+	return c
+}
+
+// looksLikePayload tells a raw JSON payload from a request item
+func looksLikePayload(arg string) bool {
+	arg = strings.TrimSpace(arg)
+	return strings.HasPrefix(arg, "{") || strings.HasPrefix(arg, "[")
+}
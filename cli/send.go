@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+// httpFileArgs completes .http and .rest files
+func httpFileArgs(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"http", "rest"}, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// requestNames completes the --name flag with the requests of the file
+func requestNames(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	data, err := cmd.LoadHTTPFile(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, req := range data.Requests {
+		names = append(names, req.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func newSendCommand() *cobra.Command {
+	var sel cmd.RequestSelector
+	c := &cobra.Command{
+		Use:   "send <file.http>",
+		Short: "Send requests of a .http file without opening the TUI",
+		Long: `Send requests of a .http file without opening the TUI. Pick the request
+with --name or --index (starting at 1), or send every request in order with
+--all. The {{variables}} are resolved from the file globals and --env.`,
+		Example: `  postbear send api.http --name "list users" --env staging
+  postbear send api.http --index 2 -s
+  postbear send api.http --all --output json`,
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			picked := 0
+			for _, set := range []bool{sel.Name != "", c.Flags().Changed("index"), sel.All} {
+				if set {
+					picked++
+				}
+			}
+			if picked != 1 {
+				return usageError{fmt.Errorf("exactly one of --name, --index or --all is required")}
+			}
+			if c.Flags().Changed("index") && sel.Index < 1 {
+				return usageError{fmt.Errorf("--index starts at 1")}
+			}
+			opts, err := cliOptions()
+			if err != nil {
+				return err
+			}
+			return cmd.SendFromFile(args[0], sel, envName, opts)
+		},
+	}
+	c.Flags().StringVarP(&sel.Name, "name", "n", "", "name of the request to send")
+	c.Flags().IntVarP(&sel.Index, "index", "i", 0, "position of the request to send, starting at 1")
+	c.Flags().BoolVarP(&sel.All, "all", "a", false, "send every request of the file in order")
+	c.RegisterFlagCompletionFunc("name", requestNames)
+	addRequestFlags(c)
+	return c
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/carban/postbear/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+func newTestCommand() *cobra.Command {
+	var names []string
+	var dataFile string
+	var opts cmd.RunnerOptions
+	c := &cobra.Command{
+		Use:   "test <file.http>",
+		Short: "Run the requests of a .http file in order and fail on errors",
+		Long: `Run every request of a .http file in order, or only the named ones. A request
+fails when it cannot be sent or answers with a 4xx/5xx status, and the command
+exits with a non-zero code if any request failed.
+
+With --data the requests run once per row of a CSV file (its first line names
+the variables) or of a JSON array of objects, the row values filling the
+{{variables}} of the requests.
+
+--update-snapshots records the normalised response bodies in a __snapshots__
+directory next to the file, and later runs fail with a diff when a response
+no longer matches its snapshot, or when a request has none yet. A "# @snapshotIgnore id, createdAt" line after
+the request line leaves the listed JSON paths out of its snapshot.
+
+When the file has an OpenAPI spec, set with the openapiSpec variable (in the
+environment or as a global variable) or found as openapi.yaml next to it, the
+status, headers and body of every response are validated against the
+operation matching the request, and violations fail the request.`,
+		Example: `  postbear test api.http
+  postbear test api.http --name login --name "list users" --env staging
+  postbear test api.http --data users.csv --delay 200ms --stop-on-failure
+  postbear test api.http --update-snapshots`,
+		Args:              usageArgs(cobra.ExactArgs(1)),
+		ValidArgsFunction: httpFileArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := checkOutput(); err != nil {
+				return err
+			}
+			if dataFile != "" {
+				rows, err := cmd.LoadDataRows(dataFile)
+				if err != nil {
+					return err
+				}
+				opts.Rows = rows
+			}
+			opts.Timeout = timeout
+			opts.Snapshots = true
+			opts.Contracts = true
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			iteration := 0
+			results, err := cmd.RunTests(ctx, args[0], names, envName, opts, func(r cmd.TestResult) {
+				if output == cmd.OutputJSON {
+					return
+				}
+				if len(opts.Rows) > 1 && r.Iteration != iteration {
+					iteration = r.Iteration
+					fmt.Printf("Iteration %d/%d\n", iteration, len(opts.Rows))
+				}
+				printTestResult(r)
+			})
+			if err != nil && err != context.Canceled {
+				return err
+			}
+
+			failed := 0
+			for _, r := range results {
+				if !r.Passed() {
+					failed++
+				}
+			}
+			if output == cmd.OutputJSON {
+				printTestsJSON(results)
+			} else {
+				fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+			}
+			switch {
+			case failed > 0:
+				return fmt.Errorf("%d of %d requests failed", failed, len(results))
+			case err != nil:
+				return fmt.Errorf("interrupted")
+			}
+			return nil
+		},
+	}
+	c.Flags().StringArrayVarP(&names, "name", "n", nil, "only run the request with this name, can be repeated")
+	c.Flags().StringVar(&dataFile, "data", "", "CSV or JSON file with one iteration per row")
+	c.Flags().BoolVar(&opts.StopOnFailure, "stop-on-failure", false, "stop at the first failed request")
+	c.Flags().DurationVar(&opts.Delay, "delay", 0, "wait between two requests, e.g. 500ms")
+	c.Flags().BoolVar(&opts.UpdateSnapshots, "update-snapshots", false, "record the responses as the snapshots to compare later runs with")
+	c.RegisterFlagCompletionFunc("name", requestNames)
+	c.RegisterFlagCompletionFunc("data", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"csv", "json"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	return c
+}
+
+func printTestResult(r cmd.TestResult) {
+	mark, detail := "✓", fmt.Sprint(r.Status)
+	if !r.Passed() {
+		mark = "✗"
+	}
+	if r.Snapshot == cmd.SnapshotWritten {
+		detail += ", snapshot written"
+	}
+	if r.Err != nil {
+		detail = cmd.MaskSecrets(r.Err.Error())
+	}
+	fmt.Printf("%s %-7s %s  %s (%vms)\n", mark, r.Method, r.Name, detail, r.Duration.Milliseconds())
+	var snapErr cmd.SnapshotError
+	if errors.As(r.Err, &snapErr) {
+		for _, line := range strings.Split(strings.TrimRight(snapErr.String(), "\n"), "\n") {
+			fmt.Println("    " + line)
+		}
+	}
+	var contractErr cmd.ContractError
+	if errors.As(r.Err, &contractErr) {
+		for _, v := range contractErr.Report.Violations {
+			fmt.Println("    • " + cmd.MaskSecrets(v))
+		}
+	}
+}
+
+func printTestsJSON(results []cmd.TestResult) {
+	type jsonResult struct {
+		Iteration  int      `json:"iteration"`
+		Name       string   `json:"name"`
+		Method     string   `json:"method"`
+		URL        string   `json:"url"`
+		Status     int      `json:"status"`
+		DurationMs int64    `json:"durationMs"`
+		Passed     bool     `json:"passed"`
+		Snapshot   string   `json:"snapshot,omitempty"`
+		Error      string   `json:"error,omitempty"`
+		Diff       []string `json:"diff,omitempty"`
+		Violations []string `json:"violations,omitempty"`
+	}
+	out := []jsonResult{}
+	for _, r := range results {
+		jr := jsonResult{
+			Iteration:  r.Iteration,
+			Name:       r.Name,
+			Method:     r.Method,
+			URL:        cmd.MaskSecrets(r.URL),
+			Status:     r.Status,
+			DurationMs: r.Duration.Milliseconds(),
+			Passed:     r.Passed(),
+			Snapshot:   r.Snapshot,
+		}
+		if r.Err != nil {
+			jr.Error = cmd.MaskSecrets(r.Err.Error())
+		}
+		var snapErr cmd.SnapshotError
+		if errors.As(r.Err, &snapErr) {
+			jr.Diff = snapErr.Lines
+		}
+		var contractErr cmd.ContractError
+		if errors.As(r.Err, &contractErr) {
+			for _, v := range contractErr.Report.Violations {
+				jr.Violations = append(jr.Violations, cmd.MaskSecrets(v))
+			}
+		}
+		out = append(out, jr)
+	}
+	b, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(b))
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// version is set at build time with -ldflags "-X github.com/carban/postbear/cli.version=v1.2.3"
+var version = ""
+
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version of postbear",
+		Args:  usageArgs(cobra.NoArgs),
+		Run: func(c *cobra.Command, args []string) {
+			fmt.Println("postbear " + currentVersion())
+		},
+	}
+}
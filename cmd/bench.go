@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BenchOptions configures a load test. It stops after Requests requests or
+// after Duration, whichever comes first.
+type BenchOptions struct {
+	Concurrency int
+	Requests    int           // 0 for no limit, then Duration is required
+	Duration    time.Duration // 0 for no limit
+	Rate        float64       // requests per second over all workers, 0 for no limit
+	Timeout     time.Duration // per request, 0 for none
+}
+
+// BenchReport sums up the requests a load test sent so far
+type BenchReport struct {
+	Sent       int
+	Errors     int
+	Elapsed    time.Duration
+	Throughput float64 // requests per second
+	Min        time.Duration
+	Mean       time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	Statuses   map[int]int
+	ErrorKinds map[string]int
+	Done       bool
+
+	latencies []time.Duration // sorted
+}
+
+// Bench fires one request concurrently with a pool of workers
+type Bench struct {
+	req  HTTPRequest
+	opts BenchOptions
+
+	mu        sync.Mutex
+	start     time.Time
+	end       time.Time
+	latencies []time.Duration
+	statuses  map[int]int
+	errors    map[string]int
+	done      bool
+}
+
+// NewBench prepares a load test of req, its variables already resolved
+func NewBench(req HTTPRequest, opts BenchOptions) (*Bench, error) {
+	if opts.Concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+	if opts.Requests <= 0 && opts.Duration <= 0 {
+		return nil, fmt.Errorf("set a number of requests or a duration")
+	}
+	if opts.Rate < 0 {
+		return nil, fmt.Errorf("rate can't be negative")
+	}
+	if strings.EqualFold(req.Method, "GRPC") {
+		return nil, fmt.Errorf("benchmarking gRPC requests is not supported")
+	}
+	if _, err := url.ParseRequestURI(req.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL %q", req.URL)
+	}
+	return &Bench{
+		req:      req,
+		opts:     opts,
+		statuses: map[int]int{},
+		errors:   map[string]int{},
+	}, nil
+}
+
+// Run sends the requests and returns when the test is over or ctx is done
+func (b *Bench) Run(ctx context.Context) {
+	if b.opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.opts.Duration)
+		defer cancel()
+	}
+	b.mu.Lock()
+	b.start = time.Now()
+	b.mu.Unlock()
+
+	method := strings.ToUpper(b.req.Method)
+	headers := http.Header{}
+	for _, line := range HeaderLines(b.req.Headers) {
+		if key, value, err := ParseHeaderLine(line); err == nil {
+			headers.Set(key, value)
+		}
+	}
+	client := &http.Client{
+		Timeout: b.opts.Timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConnsPerHost: b.opts.Concurrency,
+		},
+	}
+
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if b.opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / b.opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for i := 0; b.opts.Requests <= 0 || i < b.opts.Requests; i++ {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < b.opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				var body io.Reader
+				if b.req.Body != "" {
+					body = strings.NewReader(b.req.Body)
+				}
+				httpReq, err := http.NewRequestWithContext(ctx, method, b.req.URL, body)
+				if err != nil {
+					b.record(0, 0, err)
+					continue
+				}
+				httpReq.Header = headers.Clone()
+				startTime := time.Now()
+				resp, err := client.Do(httpReq)
+				if err != nil {
+					// Requests cut by the end of the test don't count
+					if ctx.Err() == nil {
+						b.record(time.Since(startTime), 0, err)
+					}
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				b.record(time.Since(startTime), resp.StatusCode, nil)
+			}
+		}()
+	}
+	wg.Wait()
+	client.CloseIdleConnections()
+
+	b.mu.Lock()
+	b.end = time.Now()
+	b.done = true
+	b.mu.Unlock()
+}
+
+func (b *Bench) record(latency time.Duration, status int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		b.errors[err.Error()]++
+		return
+	}
+	b.latencies = append(b.latencies, latency)
+	b.statuses[status]++
+}
+
+// Report sums up the requests sent so far, it is safe to call while the
+// test runs
+func (b *Bench) Report() BenchReport {
+	b.mu.Lock()
+	r := BenchReport{
+		Statuses:   make(map[int]int, len(b.statuses)),
+		ErrorKinds: make(map[string]int, len(b.errors)),
+		Done:       b.done,
+		latencies:  append([]time.Duration(nil), b.latencies...),
+	}
+	for k, v := range b.statuses {
+		r.Statuses[k] = v
+	}
+	for k, v := range b.errors {
+		r.ErrorKinds[k] = v
+		r.Errors += v
+	}
+	switch {
+	case b.start.IsZero():
+	case b.done:
+		r.Elapsed = b.end.Sub(b.start)
+	default:
+		r.Elapsed = time.Since(b.start)
+	}
+	b.mu.Unlock()
+
+	r.Sent = len(r.latencies) + r.Errors
+	if r.Elapsed > 0 {
+		r.Throughput = float64(r.Sent) / r.Elapsed.Seconds()
+	}
+	if len(r.latencies) == 0 {
+		return r
+	}
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	var total time.Duration
+	for _, l := range r.latencies {
+		total += l
+	}
+	r.Min = r.latencies[0]
+	r.Max = r.latencies[len(r.latencies)-1]
+	r.Mean = total / time.Duration(len(r.latencies))
+	r.P50 = r.percentile(50)
+	r.P90 = r.percentile(90)
+	r.P99 = r.percentile(99)
+	return r
+}
+
+// percentile uses the nearest rank of the sorted latencies
+func (r BenchReport) percentile(p float64) time.Duration {
+	rank := int(p/100*float64(len(r.latencies))+0.5) - 1
+	return r.latencies[min(max(rank, 0), len(r.latencies)-1)]
+}
+
+// histogram counts the latencies in n buckets of the same width between
+// the fastest request and p99, the slower outliers would squash every other
+// bar so they get a last bucket of their own
+func (r BenchReport) histogram(n int) ([]time.Duration, []int, int) {
+	if len(r.latencies) == 0 || n < 1 {
+		return nil, nil, 0
+	}
+	width := (r.P99 - r.Min) / time.Duration(n)
+	if width <= 0 {
+		return []time.Duration{r.Max}, []int{len(r.latencies)}, 0
+	}
+	bounds := make([]time.Duration, n)
+	counts := make([]int, n)
+	for i := range bounds {
+		bounds[i] = r.Min + width*time.Duration(i+1)
+	}
+	slower := 0
+	for _, l := range r.latencies {
+		if l > r.P99 {
+			slower++
+			continue
+		}
+		counts[min(int((l-r.Min)/width), n-1)]++
+	}
+	return bounds, counts, slower
+}
+
+var histogramBarStyle = lipgloss.NewStyle().Foreground(green)
+
+// RenderHistogram draws the latency histogram with bars up to width cells
+func (r BenchReport) RenderHistogram(buckets, width int) string {
+	bounds, counts, slower := r.histogram(buckets)
+	most := slower
+	for _, c := range counts {
+		most = max(most, c)
+	}
+	bar := func(count int) string {
+		if most == 0 {
+			return ""
+		}
+		return histogramBarStyle.Render(strings.Repeat("█", count*max(width, 1)/most))
+	}
+	var sb strings.Builder
+	for i, bound := range bounds {
+		sb.WriteString(fmt.Sprintf("%10s | %s %d\n", formatLatency(bound), bar(counts[i]), counts[i]))
+	}
+	if slower > 0 {
+		sb.WriteString(fmt.Sprintf("%10s | %s %d\n", ">"+formatLatency(r.P99), bar(slower), slower))
+	}
+	return sb.String()
+}
+
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%dµs", d.Microseconds())
+}
+
+// String renders the report as text, without the histogram
+func (r BenchReport) String() string {
+	labelStyle := boldStyle.Foreground(lipgloss.Color("6"))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %d sent, %d errors in %.2fs\n", labelStyle.Render("Requests:  "), r.Sent, r.Errors, r.Elapsed.Seconds()))
+	sb.WriteString(fmt.Sprintf("%s %.1f req/s\n", labelStyle.Render("Throughput:"), r.Throughput))
+	sb.WriteString(fmt.Sprintf("%s min %s  mean %s  p50 %s  p90 %s  p99 %s  max %s\n", labelStyle.Render("Latency:   "),
+		formatLatency(r.Min), formatLatency(r.Mean), formatLatency(r.P50), formatLatency(r.P90), formatLatency(r.P99), formatLatency(r.Max)))
+
+	codes := make([]int, 0, len(r.Statuses))
+	for code := range r.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	sb.WriteString(labelStyle.Render("Status codes:") + "\n")
+	for _, code := range codes {
+		sb.WriteString(fmt.Sprintf("  %s %d\n", statusCodeStyle(fmt.Sprint(code)).Render(fmt.Sprint(code)), r.Statuses[code]))
+	}
+	if len(r.ErrorKinds) > 0 {
+		kinds := make([]string, 0, len(r.ErrorKinds))
+		for kind := range r.ErrorKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		sb.WriteString(labelStyle.Render("Errors:") + "\n")
+		for _, kind := range kinds {
+			sb.WriteString(fmt.Sprintf("  %dx %s\n", r.ErrorKinds[kind], kind))
+		}
+	}
+	return sb.String()
+}
+
+// BenchFromFile prepares a load test of a request of a .http file
+func BenchFromFile(file string, sel RequestSelector, envName string, opts BenchOptions) (*Bench, error) {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := SelectRequests(data, sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) != 1 {
+		return nil, fmt.Errorf("pick one request to benchmark")
+	}
+	vars, err := ResolveVariables(file, envName)
+	if err != nil {
+		return nil, err
+	}
+	return NewBench(ResolveRequest(requests[0], vars), opts)
+}
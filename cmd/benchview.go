@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Load test of the TUI, run on the selected request
+var tuiBenchOptions = BenchOptions{
+	Concurrency: 10,
+	Requests:    500,
+	Timeout:     30 * time.Second,
+}
+
+const benchRefresh = 250 * time.Millisecond
+
+type benchTickMsg struct {
+	bench *Bench
+}
+
+func benchTick(b *Bench) tea.Cmd {
+	return tea.Tick(benchRefresh, func(time.Time) tea.Msg {
+		return benchTickMsg{bench: b}
+	})
+}
+
+type benchScreen struct {
+	width       int
+	height      int
+	styles      *Styles
+	returnModel Model
+	title       string
+	bench       *Bench
+	cancel      context.CancelFunc
+	report      BenchReport
+	err         error
+}
+
+// benchRequest is the request in the fields with its variables resolved
+func benchRequest(m Model) HTTPRequest {
+	req := HTTPRequest{
+		Method:     strings.ToUpper(strings.TrimSpace(m.methodField.Value())),
+		URL:        strings.TrimSpace(m.urlField.Value()),
+		Headers:    strings.TrimSpace(m.headersArea.Value()),
+		Directives: m.editedRequest().Directives,
+	}
+	// Same as sendByTUI, only these methods carry the body
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+		req.Body = m.bodyArea.Value()
+	}
+	return substituteVariables(req, m.variablesFor(m.environment))
+}
+
+func newBenchScreen(m Model) (benchScreen, tea.Cmd) {
+	req := benchRequest(m)
+	s := benchScreen{
+		width:       m.width,
+		height:      m.height,
+		styles:      m.styles,
+		returnModel: m,
+		title:       fmt.Sprintf("POSTBEAR Benchmark  %s %s", req.Method, req.URL),
+	}
+	return s.start(req)
+}
+
+func (s benchScreen) start(req HTTPRequest) (benchScreen, tea.Cmd) {
+	bench, err := NewBench(req, tuiBenchOptions)
+	if err != nil {
+		s.err = err
+		return s, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.bench, s.cancel, s.err = bench, cancel, nil
+	s.report = BenchReport{}
+	go bench.Run(ctx)
+	return s, benchTick(bench)
+}
+
+func (s benchScreen) stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+func (s benchScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s benchScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+	case benchTickMsg:
+		// Ticks of a previous run are dropped
+		if msg.bench != s.bench {
+			return s, nil
+		}
+		s.report = s.bench.Report()
+		if s.report.Done {
+			return s, nil
+		}
+		return s, benchTick(s.bench)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			s.stop()
+			return s.returnModel.confirmQuit()
+		case "esc":
+			s.stop()
+			s.returnModel.width = s.width
+			s.returnModel.height = s.height
+			return s.returnModel, s.returnModel.restartWatch()
+		case "r":
+			s.stop()
+			return s.start(benchRequest(s.returnModel))
+		}
+	}
+	return s, nil
+}
+
+func (s benchScreen) View() string {
+	header := s.appTopLabel(s.title)
+
+	var b strings.Builder
+	switch {
+	case s.err != nil:
+		b.WriteString(codes500Style.Render(" " + s.err.Error() + " "))
+	default:
+		opts := tuiBenchOptions
+		state := "Running..."
+		if s.report.Done {
+			state = "Done"
+		}
+		b.WriteString(fmt.Sprintf("%s  %d/%d requests, %d workers\n\n", boldStyle.Render(state), s.report.Sent, opts.Requests, opts.Concurrency))
+		b.WriteString(s.report.String() + "\n")
+		b.WriteString(boldStyle.Foreground(lipgloss.Color("13")).Render("Latency histogram:") + "\n")
+		buckets := max(min(s.height-24, 12), 3)
+		b.WriteString(s.report.RenderHistogram(buckets, max(s.width-40, 10)))
+	}
+
+	body := borderStyle.Width(s.width - 2).Height(s.height - 4).Render(lipgloss.NewStyle().Padding(0, 1).Render(b.String()))
+	footer := s.appBottomLabel("r to run again, <ESC> to stop and go back")
+	return s.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
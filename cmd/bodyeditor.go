@@ -0,0 +1,849 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Languages of the body editor, plain text has none
+const (
+	langJSON    = "json"
+	langXML     = "xml"
+	langGraphQL = "graphql"
+)
+
+// syntaxClass is what a character of the editor is, for its color
+type syntaxClass int
+
+const (
+	classPlain syntaxClass = iota
+	classKey
+	classString
+	classNumber
+	classLiteral
+	classKeyword
+	classTag
+	classAttribute
+	classComment
+	classPunctuation
+	classMatch // the bracket of the cursor and its match
+	classError // where the body stops being valid
+)
+
+// syntaxStyles color the classes, the ones missing are left as they are
+var syntaxStyles = map[syntaxClass]lipgloss.Style{
+	classKey:       lipgloss.NewStyle().Foreground(indigo),
+	classString:    lipgloss.NewStyle().Foreground(green),
+	classNumber:    lipgloss.NewStyle().Foreground(lipgloss.Color("#d3869b")),
+	classLiteral:   lipgloss.NewStyle().Foreground(lipgloss.Color("#fe8019")),
+	classKeyword:   lipgloss.NewStyle().Foreground(lipgloss.Color("#fe8019")),
+	classTag:       lipgloss.NewStyle().Foreground(indigo),
+	classAttribute: lipgloss.NewStyle().Foreground(lipgloss.Color("#fabd2f")),
+	classComment:   lipgloss.NewStyle().Faint(true),
+	classMatch:     lipgloss.NewStyle().Bold(true).Underline(true),
+	classError:     lipgloss.NewStyle().Background(lipgloss.Color("9")).Foreground(lipgloss.Color("15")),
+}
+
+// BodyError is where and why a body or the headers are not valid, Line
+// and Col start at 1
+type BodyError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *BodyError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+var contentTypeLanguages = []struct{ mediaType, lang string }{
+	{"graphql", langGraphQL},
+	{"json", langJSON},
+	{"xml", langXML},
+}
+
+var graphqlOperation = regexp.MustCompile(`^(query|mutation|subscription|fragment)\b`)
+
+// bodyLanguage picks the language of a body from the Content-Type of the
+// headers, or from how the body starts
+func bodyLanguage(headersJSON, body string) string {
+	var headers map[string]string
+	json.Unmarshal([]byte(headersJSON), &headers)
+	for k, v := range headers {
+		if !strings.EqualFold(k, "Content-Type") {
+			continue
+		}
+		for _, ct := range contentTypeLanguages {
+			if strings.Contains(strings.ToLower(v), ct.mediaType) {
+				return ct.lang
+			}
+		}
+	}
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case graphqlOperation.MatchString(trimmed):
+		return langGraphQL
+	case strings.HasPrefix(trimmed, "{"), strings.HasPrefix(trimmed, "["):
+		return langJSON
+	case strings.HasPrefix(trimmed, "<"):
+		return langXML
+	}
+	return ""
+}
+
+// replaceBarePlaceholders replaces the {{variables}} of a JSON text that
+// are not inside a string, which would not be valid JSON until sent
+func replaceBarePlaceholders(text string, replace func(i int, placeholder string) string) string {
+	var sb strings.Builder
+	inString, n := false, 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString && c == '\\' && i+1 < len(text):
+			sb.WriteString(text[i : i+2])
+			i++
+			continue
+		case c == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(text[i:], "{{"):
+			if end := strings.Index(text[i:], "}}"); end != -1 {
+				sb.WriteString(replace(n, text[i:i+end+2]))
+				n++
+				i += end + 1
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// padPlaceholder stands for a bare {{variable}} while validating, with the
+// same length so the offsets of errors are kept
+func padPlaceholder(_ int, placeholder string) string {
+	return "0" + strings.Repeat(" ", len(placeholder)-1)
+}
+
+// offsetPosition turns a byte offset of text into a line and a column
+func offsetPosition(text string, offset int) (int, int) {
+	offset = max(min(offset, len(text)), 0)
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := len([]rune(before[strings.LastIndex(before, "\n")+1:])) + 1
+	return line, col
+}
+
+// ValidateBody checks a body written in lang, nil when it is valid or has
+// no language. {{variables}} are valid anywhere a value is.
+func ValidateBody(lang, body string) *BodyError {
+	if strings.TrimSpace(body) == "" {
+		return nil
+	}
+	switch lang {
+	case langJSON:
+		return validateJSON(replaceBarePlaceholders(body, padPlaceholder), nil)
+	case langXML:
+		return validateXML(body)
+	case langGraphQL:
+		return validateBrackets(body, "#")
+	}
+	return nil
+}
+
+// ValidateHeaders checks that the headers are a JSON object of strings
+func ValidateHeaders(headers string) *BodyError {
+	if strings.TrimSpace(headers) == "" {
+		return nil
+	}
+	var values map[string]string
+	return validateJSON(replaceBarePlaceholders(headers, padPlaceholder), &values)
+}
+
+func validateJSON(text string, v any) *BodyError {
+	if v == nil {
+		var anything any
+		v = &anything
+	}
+	err := json.Unmarshal([]byte(text), v)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &syntaxErr):
+		line, col := offsetPosition(text, int(syntaxErr.Offset)-1)
+		return &BodyError{Line: line, Col: col, Msg: syntaxErr.Error()}
+	case errors.As(err, &typeErr):
+		line, col := offsetPosition(text, int(typeErr.Offset)-1)
+		return &BodyError{Line: line, Col: col, Msg: fmt.Sprintf("%s should be a string", typeErr.Value)}
+	}
+	line, col := offsetPosition(text, len(text))
+	return &BodyError{Line: line, Col: col, Msg: err.Error()}
+}
+
+func validateXML(text string) *BodyError {
+	d := xml.NewDecoder(strings.NewReader(text))
+	d.Strict = true
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			msg := err.Error()
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				msg = syntaxErr.Msg
+			}
+			line, col := offsetPosition(text, int(d.InputOffset())-1)
+			return &BodyError{Line: line, Col: col, Msg: msg}
+		}
+	}
+}
+
+// validateBrackets checks that the brackets and strings of text are
+// closed, comment starts a comment up to the end of the line
+func validateBrackets(text, comment string) *BodyError {
+	var open []int
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case strings.HasPrefix(text[i:], comment):
+			if end := strings.IndexByte(text[i:], '\n'); end != -1 {
+				i += end
+			} else {
+				i = len(text)
+			}
+		case c == '"':
+			end := stringEnd(text, i)
+			if end == -1 {
+				line, col := offsetPosition(text, i)
+				return &BodyError{Line: line, Col: col, Msg: "unterminated string"}
+			}
+			i = end
+		case strings.IndexByte("{[(", c) != -1:
+			open = append(open, i)
+		case strings.IndexByte("}])", c) != -1:
+			if len(open) == 0 || closingBracket(text[open[len(open)-1]]) != c {
+				line, col := offsetPosition(text, i)
+				return &BodyError{Line: line, Col: col, Msg: fmt.Sprintf("unexpected %q", c)}
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		i := open[len(open)-1]
+		line, col := offsetPosition(text, i)
+		return &BodyError{Line: line, Col: col, Msg: fmt.Sprintf("%q is never closed", text[i])}
+	}
+	return nil
+}
+
+// stringEnd returns the offset of the closing quote of the string starting
+// at start, -1 when it is not closed on its line. GraphQL """block
+// strings""" can span lines.
+func stringEnd(text string, start int) int {
+	if strings.HasPrefix(text[start:], `"""`) {
+		if end := strings.Index(text[start+3:], `"""`); end != -1 {
+			return start + 3 + end + 2
+		}
+		return -1
+	}
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+func closingBracket(c byte) byte {
+	switch c {
+	case '{':
+		return '}'
+	case '[':
+		return ']'
+	}
+	return ')'
+}
+
+// PrettifyBody indents a body written in lang
+func PrettifyBody(lang, body string) (string, error) {
+	return formatBody(lang, body, false)
+}
+
+// MinifyBody removes the whitespace between the tokens of a body
+func MinifyBody(lang, body string) (string, error) {
+	return formatBody(lang, body, true)
+}
+
+func formatBody(lang, body string, minify bool) (string, error) {
+	if err := ValidateBody(lang, body); err != nil {
+		return body, err
+	}
+	switch lang {
+	case langJSON:
+		return formatJSONBody(body, minify)
+	case langXML:
+		return formatXML(body, minify)
+	case langGraphQL:
+		return formatGraphQL(body, minify), nil
+	}
+	return body, fmt.Errorf("no JSON, XML or GraphQL to format")
+}
+
+// formatJSONBody keeps the order of the keys and the bare {{variables}},
+// which stand for numbers while formatting
+func formatJSONBody(body string, minify bool) (string, error) {
+	var placeholders []string
+	text := replaceBarePlaceholders(body, func(i int, placeholder string) string {
+		placeholders = append(placeholders, placeholder)
+		return fmt.Sprintf("-0.%de-99999", i+1)
+	})
+	var buf bytes.Buffer
+	var err error
+	if minify {
+		err = json.Compact(&buf, []byte(text))
+	} else {
+		err = json.Indent(&buf, []byte(text), "", "  ")
+	}
+	if err != nil {
+		return body, err
+	}
+	out := buf.String()
+	for i := len(placeholders) - 1; i >= 0; i-- {
+		out = strings.Replace(out, fmt.Sprintf("-0.%de-99999", i+1), placeholders[i], 1)
+	}
+	return out, nil
+}
+
+// formatXML writes each element on its own line, the ones holding only
+// text on one line. The tokens are copied as written.
+func formatXML(body string, minify bool) (string, error) {
+	type rawToken struct {
+		token xml.Token
+		text  string
+	}
+	var tokens []rawToken
+	d := xml.NewDecoder(strings.NewReader(body))
+	d.Strict = true
+	for {
+		start := d.InputOffset()
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return body, err
+		}
+		text := body[start:d.InputOffset()]
+		if text == "" {
+			// The end of a <self-closing/> element
+			continue
+		}
+		if _, ok := t.(xml.CharData); ok {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			text = strings.TrimSpace(text)
+		}
+		tokens = append(tokens, rawToken{token: xml.CopyToken(t), text: text})
+	}
+	var sb strings.Builder
+	depth := 0
+	newline := func() {
+		if !minify && sb.Len() > 0 {
+			sb.WriteString("\n" + strings.Repeat("  ", depth))
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].token.(type) {
+		case xml.StartElement:
+			newline()
+			// <a>text</a> stays on one line
+			if i+2 < len(tokens) {
+				_, text := tokens[i+1].token.(xml.CharData)
+				_, end := tokens[i+2].token.(xml.EndElement)
+				if text && end {
+					sb.WriteString(tokens[i].text + tokens[i+1].text + tokens[i+2].text)
+					i += 2
+					continue
+				}
+			}
+			sb.WriteString(tokens[i].text)
+			if !strings.HasSuffix(tokens[i].text, "/>") {
+				depth++
+			}
+		case xml.EndElement:
+			depth--
+			newline()
+			sb.WriteString(tokens[i].text)
+		default:
+			newline()
+			sb.WriteString(tokens[i].text)
+		}
+	}
+	return sb.String(), nil
+}
+
+// formatGraphQL puts each field of a selection set on its own line, or
+// keeps only the spaces needed between the tokens. Arguments stay on the
+// line of their field.
+func formatGraphQL(body string, minify bool) string {
+	var sb strings.Builder
+	var open []string // brackets not closed yet
+	prev, lineStart := "", true
+	inArguments := func() bool { return slices.Contains(open, "(") }
+	newline := func() {
+		if !minify && !lineStart {
+			sb.WriteString("\n")
+			lineStart = true
+		}
+	}
+	write := func(token string) {
+		switch {
+		case lineStart:
+			if !minify {
+				sb.WriteString(strings.Repeat("  ", strings.Count(strings.Join(open, ""), "{")))
+			}
+			lineStart = false
+		case graphqlSpaced(prev, token, minify):
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(token)
+		prev = token
+	}
+	for rest := strings.TrimSpace(body); rest != ""; rest = strings.TrimLeftFunc(rest, unicode.IsSpace) {
+		token := graphqlToken.FindString(rest)
+		if token == "" {
+			_, size := firstRune(rest)
+			token = rest[:size]
+		}
+		rest = rest[len(token):]
+		switch {
+		case strings.HasPrefix(token, "#"):
+			if !minify {
+				write(token)
+				newline()
+			}
+		case token == "," && minify:
+		case token == "{" && !inArguments():
+			write(token)
+			open = append(open, token)
+			newline()
+		case token == "}" && !inArguments():
+			open = open[:max(len(open)-1, 0)]
+			newline()
+			write(token)
+			newline()
+		case token == "{" || token == "(" || token == "[":
+			write(token)
+			open = append(open, token)
+		case token == "}" || token == ")" || token == "]":
+			open = open[:max(len(open)-1, 0)]
+			write(token)
+		default:
+			if len(open) > 0 && !inArguments() && graphqlFieldStart(prev, token) {
+				newline()
+			}
+			write(token)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// graphqlWord is a token that needs a space before the next word
+func graphqlWord(token string) bool {
+	c := token[0]
+	return unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)) || strings.IndexByte(`_$@"-`, c) != -1 || strings.HasPrefix(token, "{{")
+}
+
+// graphqlSpaced tells if a space goes between two tokens of a line
+func graphqlSpaced(prev, token string, minify bool) bool {
+	if minify {
+		return graphqlWord(prev) && graphqlWord(token)
+	}
+	switch {
+	case prev == "(" || prev == "[" || prev == "...":
+		return false
+	case strings.Contains("):]!,", token):
+		return false
+	case token == "(" || token == "[":
+		return prev == ":" || prev == "="
+	}
+	return true
+}
+
+// graphqlFieldStart tells if token starts a field of a selection set
+func graphqlFieldStart(prev, token string) bool {
+	switch {
+	case prev == "{" || prev == ":" || prev == "..." || prev == "on":
+		return false
+	case token == "on" || strings.HasPrefix(token, "@"):
+		return false
+	}
+	return graphqlWord(token) || token == "..."
+}
+
+var (
+	jsonToken    = regexp.MustCompile(`^(?:"(?:[^"\\\n]|\\.)*"?|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|true|false|null|{{[^{}]*}}|[{}\[\]:,])`)
+	jsonKey      = regexp.MustCompile(`^\s*:`)
+	xmlToken     = regexp.MustCompile(`^(?:<!--(?:[^-]|-[^-])*(?:-->)?|<[/?!]?[\w:.-]*|/?>|\?>|"[^"]*"?|'[^']*'?|[\w:.-]+=?)`)
+	graphqlToken = regexp.MustCompile(`^(?:#[^\n]*|"""(?:[^"]|"[^"]|""[^"])*(?:""")?|"(?:[^"\\\n]|\\.)*"?|\$\w+|@\w+|-?\d+(?:\.\d+)?|[A-Za-z_]\w*|{{[^{}]*}}|\.\.\.|[{}()\[\]:!=,|&.])`)
+)
+
+var graphqlKeywords = map[string]bool{
+	"query": true, "mutation": true, "subscription": true, "fragment": true, "on": true,
+	"true": true, "false": true, "null": true,
+}
+
+// syntaxClasses returns the class of each rune of each line of text
+func syntaxClasses(lang, text string) [][]syntaxClass {
+	var classes []syntaxClass
+	mark := func(s string, class syntaxClass) {
+		for range s {
+			classes = append(classes, class)
+		}
+	}
+	inTag := false // XML attributes are only inside <tags>
+	for rest := text; rest != ""; {
+		var token string
+		switch lang {
+		case langJSON:
+			token = jsonToken.FindString(rest)
+		case langXML:
+			token = xmlToken.FindString(rest)
+		case langGraphQL:
+			token = graphqlToken.FindString(rest)
+		}
+		if token == "" {
+			_, size := firstRune(rest)
+			mark(rest[:size], classPlain)
+			rest = rest[size:]
+			continue
+		}
+		rest = rest[len(token):]
+		class := classPlain
+		switch lang {
+		case langJSON:
+			switch c := token[0]; {
+			case strings.HasPrefix(token, "{{"):
+			case c == '"' && jsonKey.MatchString(rest):
+				class = classKey
+			case c == '"':
+				class = classString
+			case c == '-' || c >= '0' && c <= '9':
+				class = classNumber
+			case c == 't' || c == 'f' || c == 'n':
+				class = classLiteral
+			default:
+				class = classPunctuation
+			}
+		case langXML:
+			switch {
+			case strings.HasPrefix(token, "<!--"):
+				class = classComment
+			case strings.HasPrefix(token, "<"):
+				class, inTag = classTag, true
+			case strings.HasSuffix(token, ">"):
+				class, inTag = classTag, false
+			case inTag && (token[0] == '"' || token[0] == '\''):
+				class = classString
+			case inTag:
+				class = classAttribute
+			}
+		case langGraphQL:
+			switch c := token[0]; {
+			case c == '#':
+				class = classComment
+			case c == '"':
+				class = classString
+			case c == '$' || c == '@':
+				class = classAttribute
+			case c == '-' || c >= '0' && c <= '9':
+				class = classNumber
+			case graphqlKeywords[token]:
+				class = classKeyword
+			case strings.HasPrefix(token, "{{"):
+			case unicode.IsLetter(rune(c)) || c == '_':
+				class = classPlain
+			default:
+				class = classPunctuation
+			}
+		}
+		mark(token, class)
+	}
+	lines := [][]syntaxClass{}
+	i := 0
+	for _, line := range strings.Split(text, "\n") {
+		n := len([]rune(line))
+		lines = append(lines, classes[i:i+n])
+		i += n + 1 // the newline
+	}
+	return lines
+}
+
+func firstRune(s string) (rune, int) {
+	for _, r := range s {
+		return r, len(string(r))
+	}
+	return 0, 0
+}
+
+// markBracketMatch marks the bracket before or under the cursor and the
+// one it matches
+func markBracketMatch(lines []string, classes [][]syntaxClass, row, col int) {
+	type pos struct{ row, col int }
+	at := func(p pos) rune {
+		r := []rune(lines[p.row])
+		if p.col < 0 || p.col >= len(r) || classes[p.row][p.col] != classPunctuation {
+			return 0
+		}
+		return r[p.col]
+	}
+	start := pos{row, col}
+	if strings.IndexRune("{}[]()", at(start)) == -1 {
+		start.col--
+	}
+	open := at(start)
+	if open == 0 || strings.IndexRune("{}[]()", open) == -1 {
+		return
+	}
+	pairs := map[rune]rune{'{': '}', '[': ']', '(': ')', '}': '{', ']': '[', ')': '('}
+	step := 1
+	if strings.IndexRune("}])", open) != -1 {
+		step = -1
+	}
+	depth := 0
+	for p := start; p.row >= 0 && p.row < len(lines); {
+		switch at(p) {
+		case open:
+			depth++
+		case pairs[open]:
+			depth--
+			if depth == 0 {
+				classes[start.row][start.col] = classMatch
+				classes[p.row][p.col] = classMatch
+				return
+			}
+		}
+		p.col += step
+		for p.row >= 0 && p.row < len(lines) && (p.col < 0 || p.col >= len([]rune(lines[p.row]))) {
+			p.row += step
+			if p.row < 0 || p.row >= len(lines) {
+				return
+			}
+			p.col = 0
+			if step < 0 {
+				p.col = len([]rune(lines[p.row])) - 1
+			}
+		}
+	}
+}
+
+// highlightSyntax colors the rendered view of an editor with classes, by
+// the line numbers of its gutter. The cursor line keeps its own colors, but
+// for the bracket match and the error.
+func highlightSyntax(view string, area textarea.Model, classes [][]syntaxClass) string {
+	lines := strings.Split(area.Value(), "\n")
+	gutter := len(strconv.Itoa(area.MaxHeight)) + 2
+	var out []string
+	row, col := -1, 0
+	for _, viewLine := range strings.Split(view, "\n") {
+		plain := []rune(sgrSequence.ReplaceAllString(viewLine, ""))
+		if len(plain) <= gutter {
+			out = append(out, viewLine)
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(string(plain[:gutter]))); err == nil {
+			row, col = n-1, 0
+		} else if row >= 0 && row < len(lines) {
+			// A wrapped line, the space it was wrapped at may be left out
+			text := []rune(lines[row])
+			if col < len(text) && text[col] == ' ' && plain[gutter] != ' ' {
+				col++
+			}
+		}
+		if row < 0 || row >= len(classes) {
+			out = append(out, viewLine)
+			continue
+		}
+		var sb strings.Builder
+		style := ""
+		skip := gutter
+		cursorLine := area.Focused() && row == area.Line()
+		for rest := viewLine; rest != ""; {
+			if loc := sgrSequence.FindStringIndex(rest); loc != nil && loc[0] == 0 {
+				style = rest[:loc[1]]
+				sb.WriteString(style)
+				rest = rest[loc[1]:]
+				continue
+			}
+			end := len(rest)
+			if loc := sgrSequence.FindStringIndex(rest); loc != nil {
+				end = loc[0]
+			}
+			text := []rune(rest[:end])
+			rest = rest[end:]
+			for len(text) > 0 {
+				if skip > 0 {
+					n := min(skip, len(text))
+					sb.WriteString(string(text[:n]))
+					text, skip = text[n:], skip-n
+					continue
+				}
+				class := classAt(classes, row, col)
+				n := 1
+				for n < len(text) && classAt(classes, row, col+n) == class {
+					n++
+				}
+				if classStyle, ok := syntaxStyles[class]; ok && (!cursorLine || class == classMatch || class == classError) {
+					sb.WriteString(classStyle.Render(string(text[:n])) + style)
+				} else {
+					sb.WriteString(string(text[:n]))
+				}
+				text, col = text[n:], col+n
+			}
+		}
+		out = append(out, sb.String())
+	}
+	return strings.Join(out, "\n")
+}
+
+func classAt(classes [][]syntaxClass, row, col int) syntaxClass {
+	if row < 0 || row >= len(classes) || col < 0 || col >= len(classes[row]) {
+		return classPlain
+	}
+	return classes[row][col]
+}
+
+// autoIndent breaks the line at the cursor keeping its indentation, one
+// level more after an opening bracket, and a closing bracket right after
+// the cursor goes on its own line
+func autoIndent(area *textarea.Model) {
+	lines := strings.Split(area.Value(), "\n")
+	if area.Line() >= len(lines) {
+		area.InsertString("\n")
+		return
+	}
+	line := []rune(lines[area.Line()])
+	info := area.LineInfo()
+	col := min(info.StartColumn+info.ColumnOffset, len(line))
+	indent := string(line[:len(line)-len([]rune(strings.TrimLeft(string(line), " \t")))])
+	before := strings.TrimRight(string(line[:col]), " ")
+	after := strings.TrimLeft(string(line[col:]), " ")
+	if before == "" || strings.IndexByte("{[(", before[len(before)-1]) == -1 {
+		area.InsertString("\n" + indent)
+		return
+	}
+	area.InsertString("\n" + indent + "  ")
+	if after != "" && strings.IndexByte("}])", after[0]) != -1 {
+		area.InsertString("\n" + indent)
+		area.CursorUp()
+		area.CursorEnd()
+	}
+}
+
+// editorArea is the editor of the active tab, nil when it has none
+func (m *Model) editorArea() *textarea.Model {
+	switch m.activeTab {
+	case bodyTab:
+		return &m.bodyArea
+	case headersTab:
+		return &m.headersArea
+	}
+	return nil
+}
+
+// editorLanguage is the language of the editor of the active tab
+func (m Model) editorLanguage() string {
+	if m.activeTab == headersTab {
+		return langJSON
+	}
+	return bodyLanguage(m.headersArea.Value(), m.bodyArea.Value())
+}
+
+// editorError validates the editor of the active tab
+func (m Model) editorError() *BodyError {
+	switch m.activeTab {
+	case bodyTab:
+		return ValidateBody(m.editorLanguage(), m.bodyArea.Value())
+	case headersTab:
+		return ValidateHeaders(m.headersArea.Value())
+	}
+	return nil
+}
+
+// editorView renders the editor of the active tab with its syntax, the
+// error and the bracket matching the cursor
+func (m Model) editorView() string {
+	area := m.editorArea()
+	lang := m.editorLanguage()
+	if area.Value() == "" || lang == "" {
+		return area.View()
+	}
+	lines := strings.Split(area.Value(), "\n")
+	classes := syntaxClasses(lang, area.Value())
+	if err := m.editorError(); err != nil {
+		row, col := min(err.Line, len(classes))-1, err.Col-1
+		// Past the end of a line, the last character is marked
+		for row > 0 && len(classes[row]) == 0 {
+			row--
+			col = len(classes[row])
+		}
+		if row < len(classes) && len(classes[row]) > 0 {
+			classes[row][min(col, len(classes[row])-1)] = classError
+		}
+	}
+	if area.Focused() && area.Line() < len(lines) {
+		info := area.LineInfo()
+		markBracketMatch(lines, classes, area.Line(), info.StartColumn+info.ColumnOffset)
+	}
+	return highlightSyntax(area.View(), *area, classes)
+}
+
+// editorHint tells why the editor of the active tab is not valid
+func (m Model) editorHint() string {
+	if m.focused != tabContentPanel {
+		return ""
+	}
+	err := m.editorError()
+	if err == nil {
+		return ""
+	}
+	name := "Headers"
+	if m.activeTab == bodyTab {
+		name = strings.ToUpper(m.editorLanguage())
+	}
+	return undefinedVariableStyle.Render(fmt.Sprintf("Invalid %s at %s", name, err.Error()))
+}
+
+// formatEditor prettifies or minifies the editor of the active tab
+func (m *Model) formatEditor(minify bool) {
+	area := m.editorArea()
+	if area == nil {
+		return
+	}
+	formatted, err := formatBody(m.editorLanguage(), area.Value(), minify)
+	if err != nil {
+		m.message = m.appBoundaryMessage("Can't format: " + err.Error())
+		return
+	}
+	area.SetValue(formatted)
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const watchInterval = 2 * time.Second
+
+// fileStamp is what we last read from or wrote to a .http file, used to
+// notice when someone else changes it
+type fileStamp struct {
+	modTime time.Time
+	hash    [sha256.Size]byte
+}
+
+type watchMsg struct {
+	id int
+}
+
+func watchTick(id int) tea.Cmd {
+	return tea.Tick(watchInterval, func(time.Time) tea.Msg {
+		return watchMsg{id: id}
+	})
+}
+
+// restartWatch starts a new watch loop, used when coming back from another
+// screen since that screen drops the ticks of the current loop, and reads
+// the variables again since that screen may have changed them
+func (m *Model) restartWatch() tea.Cmd {
+	m.watchID++
+	m.refreshScope()
+	return watchTick(m.watchID)
+}
+
+func readStamp(path string) (fileStamp, bool) {
+	info, err := os.Stat(httpFilePath(path))
+	if err != nil {
+		return fileStamp{}, false
+	}
+	content, err := os.ReadFile(httpFilePath(path))
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{modTime: info.ModTime(), hash: sha256.Sum256(content)}, true
+}
+
+// openFiles returns the .http files backing the requests list
+func (m *Model) openFiles() []string {
+	if m.workspace != "" {
+		return workspaceFiles(m.requestsList.Items())
+	}
+	return []string{m.filepath}
+}
+
+func (m *Model) stampFiles() {
+	m.stamps = map[string]fileStamp{}
+	for _, file := range m.openFiles() {
+		if stamp, ok := readStamp(file); ok {
+			m.stamps[file] = stamp
+		}
+	}
+}
+
+// checkExternalChanges records the files that changed on disk since we last
+// read or wrote them
+func (m *Model) checkExternalChanges() {
+	for _, file := range m.openFiles() {
+		known, tracked := m.stamps[file]
+		info, err := os.Stat(httpFilePath(file))
+		if err != nil || (tracked && info.ModTime().Equal(known.modTime)) {
+			continue
+		}
+		stamp, ok := readStamp(file)
+		if !ok {
+			continue
+		}
+		if tracked && stamp.hash == known.hash {
+			known.modTime = stamp.modTime
+			m.stamps[file] = known
+			continue
+		}
+		if !tracked {
+			// The file did not exist when we opened it
+			m.stamps[file] = stamp
+			continue
+		}
+		if !containsString(m.conflicts, file) {
+			m.conflicts = append(m.conflicts, file)
+		}
+	}
+	if len(m.conflicts) > 0 {
+		m.message = m.appBoundaryMessage(fmt.Sprintf("%s changed on disk! Alt+r to reload, Alt+m to merge, Ctrl+s twice to overwrite", filepath.Base(m.conflicts[0])))
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) isDirty() bool {
+	if m.listDirty {
+		return true
+	}
+	for _, req := range allRequests(m.requestsList.Items()) {
+		if req.dirty {
+			return true
+		}
+	}
+	return false
+}
+
+func clearDirty(items []list.Item) []list.Item {
+	cleared := make([]list.Item, len(items))
+	for i, item := range items {
+		switch it := item.(type) {
+		case request:
+			it.dirty = false
+			it.origin = it.title
+			cleared[i] = it
+		case treeNode:
+			it.hidden = clearDirty(it.hidden)
+			cleared[i] = it
+		default:
+			cleared[i] = item
+		}
+	}
+	return cleared
+}
+
+// changedFiles lists the files whose content is not the one of their stamp
+func changedFiles(files []string, stamps map[string]fileStamp) []string {
+	var changed []string
+	for _, file := range files {
+		known, tracked := stamps[file]
+		if stamp, ok := readStamp(file); tracked && ok && stamp.hash != known.hash {
+			changed = append(changed, file)
+		}
+	}
+	return changed
+}
+
+// saveCmd writes the requests in the background and reports the new stamps.
+// Unless overwrite is set, files changed on disk since they were read are
+// reported as conflicts and nothing is written.
+func saveCmd(m Model, overwrite bool) tea.Cmd {
+	known := maps.Clone(m.stamps)
+	return func() tea.Msg {
+		if changed := changedFiles(m.openFiles(), known); len(changed) > 0 && !overwrite {
+			return saveMsg{
+				success:   false,
+				message:   fmt.Sprintf("%s changed on disk! Ctrl+s again to overwrite it, Alt+r to reload, Alt+m to merge", filepath.Base(changed[0])),
+				conflicts: changed,
+			}
+		}
+		if err := saveAllToHTTPFile(m); err != nil {
+			return saveMsg{success: false, message: "Error saving requests: " + err.Error()}
+		}
+		path := m.filepath
+		if m.workspace != "" {
+			path = m.workspace
+		}
+		stamps := map[string]fileStamp{}
+		for _, file := range m.openFiles() {
+			if stamp, ok := readStamp(file); ok {
+				stamps[file] = stamp
+			}
+		}
+		return saveMsg{
+			success: true,
+			message: fmt.Sprintf("Request Saved in %s Successfully!", path),
+			stamps:  stamps,
+		}
+	}
+}
+
+// confirmQuit quits right away when there is nothing to lose, otherwise
+// asks for a second Ctrl+c
+func (m Model) confirmQuit() (tea.Model, tea.Cmd) {
+	if !m.isDirty() || m.quitPending {
+		return m, tea.Quit
+	}
+	m.quitPending = true
+	m.message = m.appBoundaryMessage("Unsaved changes! Ctrl+c again to quit, Ctrl+s to save")
+	return m, m.restartWatch()
+}
+
+// fileRequests returns the requests of file as they are in the list
+func (m *Model) fileRequests(file string) []request {
+	if m.workspace == "" {
+		return allRequests(m.requestsList.Items())
+	}
+	var requests []request
+	for _, req := range allRequests(m.requestsList.Items()) {
+		if req.file == file {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// setFileRequests replaces the requests of file in the list
+func (m *Model) setFileRequests(file string, requests []request) {
+	var items []list.Item
+	for _, req := range requests {
+		items = append(items, req)
+	}
+	if m.workspace == "" {
+		if len(items) == 0 {
+			items = []list.Item{request{title: "New Request", desc: "GET", method: "GET", headers: createHeaders()}}
+		}
+		m.requestsList.SetItems(items)
+		m.requestsList.Select(min(m.requestsList.Index(), len(items)-1))
+		return
+	}
+	for i, item := range m.requestsList.Items() {
+		node, ok := item.(treeNode)
+		if !ok || node.kind != fileNode || node.path != file {
+			continue
+		}
+		if node.collapsed {
+			node.hidden = items
+			m.requestsList.SetItem(i, node)
+			return
+		}
+		for i+1 < len(m.requestsList.Items()) {
+			if req, ok := m.requestsList.Items()[i+1].(request); ok && req.file == file {
+				m.requestsList.RemoveItem(i + 1)
+				continue
+			}
+			break
+		}
+		for j, req := range items {
+			m.requestsList.InsertItem(i+1+j, req)
+		}
+		return
+	}
+}
+
+// diskRequests loads file as list requests
+func (m *Model) diskRequests(file string) ([]request, error) {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return nil, err
+	}
+	depth := 0
+	for _, req := range m.fileRequests(file) {
+		depth = req.depth
+		break
+	}
+	if m.workspace != "" && depth == 0 {
+		for _, item := range m.requestsList.Items() {
+			if node, ok := item.(treeNode); ok && node.path == file {
+				depth = node.depth + 1
+			}
+		}
+	}
+	var requests []request
+	for _, r := range data.Requests {
+		req := requestFromHTTP(r)
+		if m.workspace != "" {
+			req.file = file
+			req.depth = depth
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// resolveConflict reloads the first changed file, or merges it with the
+// local edits: requests edited here win, everything else comes from disk.
+// Requests are matched by the name they had when last loaded or saved.
+func (m *Model) resolveConflict(merge bool) {
+	if len(m.conflicts) == 0 {
+		return
+	}
+	file := m.conflicts[0]
+	disk, err := m.diskRequests(file)
+	if err != nil {
+		m.message = m.appBoundaryMessage("Error reading " + file + ": " + err.Error())
+		return
+	}
+
+	result := disk
+	if merge {
+		local := map[string]request{}
+		var added []request
+		for _, req := range m.fileRequests(file) {
+			if req.origin == "" {
+				added = append(added, req)
+			} else if req.dirty {
+				local[req.origin] = req
+			}
+		}
+		result = nil
+		for _, req := range disk {
+			if edited, ok := local[req.origin]; ok {
+				req = edited
+			}
+			result = append(result, req)
+		}
+		// Requests added here that the file does not have yet
+		result = append(result, added...)
+	}
+
+	m.pushUndo()
+	m.setFileRequests(file, result)
+	m.conflicts = m.conflicts[1:]
+	if stamp, ok := readStamp(file); ok {
+		m.stamps[file] = stamp
+	}
+	m.loadSelected()
+	if merge {
+		m.message = m.appBoundaryMessage(fmt.Sprintf("Merged changes from %s, Ctrl+s to save", filepath.Base(file)))
+	} else {
+		m.message = m.appBoundaryMessage(fmt.Sprintf("Reloaded %s", filepath.Base(file)))
+	}
+	if len(m.conflicts) > 0 {
+		m.checkExternalChanges()
+	}
+}
+
+// AutosaveFromEnv reports whether POSTBEAR_AUTOSAVE asks for autosave
+func AutosaveFromEnv() bool {
+	switch strings.ToLower(os.Getenv("POSTBEAR_AUTOSAVE")) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FindRequest looks a request up by name, exact match first then ignoring case
+func FindRequest(data *HTTPFileData, name string) (HTTPRequest, error) {
+	for _, req := range data.Requests {
+		if req.Name == name {
+			return req, nil
+		}
+	}
+	for _, req := range data.Requests {
+		if strings.EqualFold(req.Name, name) {
+			return req, nil
+		}
+	}
+	return HTTPRequest{}, fmt.Errorf("request %q not found", name)
+}
+
+// HeaderLines turns the JSON headers of a request into "Name: value" lines
+func HeaderLines(headersJSON string) []string {
+	if strings.TrimSpace(headersJSON) == "" {
+		return nil
+	}
+	headers, err := parseHeadersToMap(headersJSON)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for k, v := range headers {
+		lines = append(lines, k+": "+v)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// ResolveRequest replaces the {{variables}} of a request, its own
+// variables winning over vars
+func ResolveRequest(req HTTPRequest, vars map[string]string) HTTPRequest {
+	return substituteVariables(req, RequestVariables(req, vars))
+}
+
+func substituteVariables(req HTTPRequest, vars map[string]string) HTTPRequest {
+	req.URL = replacePlaceholders(ApplyPathParams(req.URL, PathValues(req.Directives)), vars)
+	req.Headers = replacePlaceholders(req.Headers, vars)
+	req.Body = replacePlaceholders(req.Body, vars)
+	return req
+}
+
+// RequestSelector picks requests of a .http file: by name, by 1-based
+// index, or all of them
+type RequestSelector struct {
+	Name  string
+	Index int
+	All   bool
+}
+
+// SelectRequests returns the requests of data picked by sel, every request
+// when sel is empty
+func SelectRequests(data *HTTPFileData, sel RequestSelector) ([]HTTPRequest, error) {
+	switch {
+	case sel.Name != "":
+		req, err := FindRequest(data, sel.Name)
+		if err != nil {
+			return nil, err
+		}
+		return []HTTPRequest{req}, nil
+	case sel.Index != 0:
+		if sel.Index < 1 || sel.Index > len(data.Requests) {
+			return nil, fmt.Errorf("request index %d out of range, the file has %d requests", sel.Index, len(data.Requests))
+		}
+		return []HTTPRequest{data.Requests[sel.Index-1]}, nil
+	}
+	return data.Requests, nil
+}
+
+// SendFromFile sends the selected requests of a .http file in order,
+// resolving their variables from the file globals and the selected
+// environment. Every request is sent even when an earlier one fails.
+func SendFromFile(file string, sel RequestSelector, envName string, opts CLIOptions) error {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return err
+	}
+	requests, err := SelectRequests(data, sel)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("%s has no requests", file)
+	}
+	vars, err := ResolveVariables(file, envName)
+	if err != nil {
+		return err
+	}
+	// Literal braces sent to the server only show up as a 404, refuse early
+	for _, req := range requests {
+		if undefined := UndefinedVariables(req, vars); len(undefined) > 0 {
+			return fmt.Errorf("%s: undefined variables %s", req.Name, strings.Join(undefined, ", "))
+		}
+	}
+	// --all --output json prints one array of the documents of the requests
+	var envelopes []cliEnvelope
+	if sel.All && opts.Output == OutputJSON {
+		envelopes = []cliEnvelope{}
+		opts.envelopes = &envelopes
+	}
+	failed := 0
+	var firstErr error
+	for i, req := range requests {
+		if i > 0 && opts.Output == OutputPretty {
+			fmt.Println()
+		}
+		if len(requests) > 1 && opts.Output == OutputPretty {
+			fmt.Println(boldStyle.Render("### " + req.Name))
+		}
+		if err := sendHTTPRequest(ResolveRequest(req, vars), vars, opts); err != nil {
+			if len(requests) == 1 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%s: %v\n", req.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if opts.envelopes != nil {
+		if err := printJSON(envelopes); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		// Keep the first error so the exit code tells what went wrong
+		return fmt.Errorf("%d of %d requests failed: %w", failed, len(requests), firstErr)
+	}
+	return nil
+}
+
+func sendHTTPRequest(req HTTPRequest, vars map[string]string, opts CLIOptions) error {
+	method := strings.ToUpper(req.Method)
+	if method == "GRPC" {
+		response, status, elapsed := sendGRPC(req.URL, req.Headers, req.Body, vars)
+		if status == "" {
+			return NetworkError{errors.New(strings.TrimSpace(MaskSecrets(response)))}
+		}
+		// " Incorrect Body " and the like, the request never left
+		if strings.HasPrefix(status, " Incorrect ") {
+			return InvalidRequestError{errors.New(grpcErrorMessage(MaskSecrets(response)))}
+		}
+		// The raw output is left untouched for pipes, the others hide secrets
+		switch opts.Output {
+		case OutputJSON:
+			ms, _ := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(elapsed), "ms"), 10, 64)
+			if err := printGRPCEnvelope(req, MaskSecrets(response), status, time.Duration(ms)*time.Millisecond, opts.envelopes); err != nil {
+				return err
+			}
+		case OutputRaw:
+			fmt.Print(response)
+		default:
+			printResesponseBody([]byte(MaskSecrets(response)))
+		}
+		if opts.Fail && status != "OK" {
+			return StatusError{Status: status}
+		}
+		return nil
+	}
+	// Flags given on the command line win over the file
+	opts.Headers = append(HeaderLines(req.Headers), opts.Headers...)
+	if opts.Body == "" {
+		opts.Body = req.Body
+	}
+	return SendByCLI(method, req.URL, opts)
+}
+
+// grpcErrorMessage joins the lines of an error message of sendGRPC, made
+// to be shown in the response pane of the TUI
+func grpcErrorMessage(response string) string {
+	var lines []string
+	for _, line := range strings.Split(response, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, ": ")
+}
+
+// SetGlobalVar sets, or removes when value is nil, a global variable of a .http file
+func SetGlobalVar(file, key string, value *string) error {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		if _, ok := data.GlobalVars[key]; !ok {
+			return fmt.Errorf("variable %q not found", key)
+		}
+		delete(data.GlobalVars, key)
+	} else {
+		data.GlobalVars[key] = *value
+	}
+	return SaveHTTPFile(data, file)
+}
+
+// AppendRequest adds a request at the end of a .http file, creating it if needed
+func AppendRequest(file string, req HTTPRequest) error {
+	data, err := LoadHTTPFile(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	data.Requests = append(data.Requests, req)
+	return SaveHTTPFile(data, file)
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContractReport is the outcome of checking a response against the
+// operation of an OpenAPI spec it answers
+type ContractReport struct {
+	Spec       string // file of the spec
+	Operation  string // "GET /users/{id}", empty when no operation matches the request
+	Violations []string
+}
+
+// Passed reports whether the response follows the contract, a request the
+// spec does not describe passes
+func (r ContractReport) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// String renders the report for people
+func (r ContractReport) String() string {
+	var sb strings.Builder
+	if r.Operation == "" {
+		sb.WriteString(fmt.Sprintf("No operation of %s matches the request\n", r.Spec))
+		return sb.String()
+	}
+	sb.WriteString(boldStyle.Render("Operation:") + " " + r.Operation + "\n")
+	sb.WriteString(boldStyle.Render("Spec:") + " " + r.Spec + "\n\n")
+	if r.Passed() {
+		sb.WriteString(codes200Style.Render(" ✓ ") + " The response follows the contract\n")
+		return sb.String()
+	}
+	sb.WriteString(codes500Style.Render(" ✗ ") + fmt.Sprintf(" %d violations\n\n", len(r.Violations)))
+	for _, v := range r.Violations {
+		sb.WriteString(diffRemovedStyle.Render("• "+v) + "\n")
+	}
+	return sb.String()
+}
+
+// ContractError is the failure of a response that breaks its contract
+type ContractError struct {
+	Report ContractReport
+}
+
+func (e ContractError) Error() string {
+	return fmt.Sprintf("response breaks the contract of %s", e.Report.Operation)
+}
+
+// CheckContract validates the status, headers and body of a response
+// against the operation of the spec matching its request
+func (s *OpenAPISpec) CheckContract(method, rawURL string, status int, header http.Header, body []byte) ContractReport {
+	report := ContractReport{Spec: s.path}
+	op := s.MatchOperation(method, rawURL)
+	if op == nil {
+		return report
+	}
+	report.Operation = op.Method + " " + op.Path
+	if op.OperationID != "" {
+		report.Operation += " (" + op.OperationID + ")"
+	}
+
+	resp, ok := s.response(op, status)
+	if !ok {
+		declared := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			declared = append(declared, code)
+		}
+		sort.Strings(declared)
+		report.Violations = append(report.Violations, fmt.Sprintf("status %d is not declared, expected %s", status, strings.Join(declared, ", ")))
+		return report
+	}
+	if resp == nil {
+		return report
+	}
+
+	names := make([]string, 0, len(resp.Headers))
+	for name := range resp.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := resp.Headers[name]
+		value := header.Get(name)
+		switch {
+		case h == nil || strings.EqualFold(name, "Content-Type"):
+		case value == "":
+			if h.Required {
+				report.Violations = append(report.Violations, fmt.Sprintf("header %s is missing", name))
+			}
+		default:
+			s.validate(h.Schema, headerValue(s.Schema(h.Schema), value), "header "+name, &report.Violations)
+		}
+	}
+
+	if len(resp.Content) == 0 || len(body) == 0 {
+		return report
+	}
+	contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	media, ok := mediaFor(resp.Content, contentType)
+	if !ok {
+		declared := make([]string, 0, len(resp.Content))
+		for ct := range resp.Content {
+			declared = append(declared, ct)
+		}
+		sort.Strings(declared)
+		report.Violations = append(report.Violations, fmt.Sprintf("Content-Type %q is not declared, expected %s", contentType, strings.Join(declared, ", ")))
+		return report
+	}
+	if media == nil || media.Schema == nil || !strings.Contains(contentType, "json") {
+		return report
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		report.Violations = append(report.Violations, "body is not valid JSON: "+err.Error())
+		return report
+	}
+	s.validate(media.Schema, value, "body", &report.Violations)
+	return report
+}
+
+// mediaFor picks the declared media type of a Content-Type, wildcards last
+func mediaFor(content map[string]*OpenAPIMediaType, contentType string) (*OpenAPIMediaType, bool) {
+	if media, ok := content[contentType]; ok {
+		return media, true
+	}
+	if i := strings.Index(contentType, "/"); i != -1 {
+		if media, ok := content[contentType[:i]+"/*"]; ok {
+			return media, true
+		}
+	}
+	media, ok := content["*/*"]
+	return media, ok
+}
+
+// headerValue converts a header to the JSON value its schema expects
+func headerValue(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch {
+	case schema.Type.has("integer"), schema.Type.has("number"):
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case schema.Type.has("boolean"):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validate appends to violations what keeps value from matching schema
+func (s *OpenAPISpec) validate(schema *Schema, value interface{}, at string, violations *[]string) {
+	schema = s.Schema(schema)
+	if schema == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, sub := range schema.AllOf {
+		s.validate(sub, value, at, violations)
+	}
+	if len(schema.AnyOf) > 0 && s.matching(schema.AnyOf, value) == 0 {
+		fail("matches none of the anyOf schemas")
+	}
+	if len(schema.OneOf) > 0 {
+		if n := s.matching(schema.OneOf, value); n != 1 {
+			fail("matches %d of the oneOf schemas instead of one", n)
+		}
+	}
+
+	if value == nil {
+		if len(schema.Type) > 0 && !schema.Nullable && !schema.Type.has("null") {
+			fail("is null, expected %s", strings.Join(schema.Type, " or "))
+		}
+		return
+	}
+	if len(schema.Type) > 0 && !typeMatches(schema.Type, value) {
+		fail("is %s, expected %s", jsonType(value), strings.Join(schema.Type, " or "))
+		return
+	}
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, e := range schema.Enum {
+			if jsonText(e) == jsonText(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed := make([]string, len(schema.Enum))
+			for i, e := range schema.Enum {
+				allowed[i] = jsonText(e)
+			}
+			fail("%s is not one of %s", jsonText(value), strings.Join(allowed, ", "))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				*violations = append(*violations, joinPath(at, name)+": required field is missing")
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := schema.Properties[k]; ok {
+				s.validate(prop, v[k], joinPath(at, k), violations)
+				continue
+			}
+			if extra := schema.AdditionalProperties; extra != nil {
+				if !extra.Allowed {
+					*violations = append(*violations, joinPath(at, k)+": field is not declared")
+				} else if extra.Schema != nil {
+					s.validate(extra.Schema, v[k], joinPath(at, k), violations)
+				}
+			}
+		}
+	case []interface{}:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			fail("has %d items, expected at least %d", len(v), *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			fail("has %d items, expected at most %d", len(v), *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range v {
+				s.validate(schema.Items, item, joinPath(at, strconv.Itoa(i)), violations)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			fail("is %d characters long, expected at least %d", length, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("is %d characters long, expected at most %d", length, *schema.MaxLength)
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(v) {
+				fail("%q does not match the pattern %s", v, schema.Pattern)
+			}
+		}
+		if !formatMatches(schema.Format, v) {
+			fail("%q is not a valid %s", v, schema.Format)
+		}
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			fail("%v is less than the minimum %v", v, *schema.Minimum)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			fail("%v is greater than the maximum %v", v, *schema.Maximum)
+		}
+	}
+}
+
+// matching counts the schemas value matches
+func (s *OpenAPISpec) matching(schemas []*Schema, value interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		var violations []string
+		s.validate(sub, value, "", &violations)
+		if len(violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func typeMatches(types schemaType, value interface{}) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == float64(int64(v))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	}
+	return "null"
+}
+
+// formatMatches checks the formats worth checking, the others pass
+func formatMatches(format, value string) bool {
+	var err error
+	switch format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse("2006-01-02", value)
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "email":
+		at := strings.LastIndex(value, "@")
+		return at > 0 && at < len(value)-1
+	}
+	return err == nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+// Tabs of the response panel, the Contract one is shown when the file has
+// an OpenAPI spec
+const (
+	responseBodyTab = iota
+	responseContractTab
+)
+
+var (
+	responseTabStyle       = lipgloss.NewStyle().Padding(0, 1).Faint(true)
+	activeResponseTabStyle = lipgloss.NewStyle().Padding(0, 1).Bold(true).Underline(true).Foreground(green)
+)
+
+// contractView checks the response of the request being edited against the
+// OpenAPI spec of its file, "" when the file has none
+func contractView(m Model, status string, header http.Header, body string) string {
+	// gRPC calls and requests that got no response have nothing to check
+	if header == nil {
+		return ""
+	}
+	vars := m.variablesFor(m.environment)
+	path := FindOpenAPISpec(m.filepath, vars)
+	if path == "" {
+		return ""
+	}
+	spec, err := LoadOpenAPI(path)
+	if err != nil {
+		return codes500Style.Render(" Failed to load the OpenAPI spec ") + "\n\n" + err.Error()
+	}
+	code, _ := strconv.Atoi(status)
+	method := strings.ToUpper(strings.TrimSpace(m.methodField.Value()))
+	url := replacePlaceholders(ApplyPathParams(strings.TrimSpace(m.urlField.Value()), PathValues(m.editedRequest().Directives)), vars)
+	return spec.CheckContract(method, url, code, header, []byte(body)).String()
+}
+
+// responseTabsView renders the tabs of the response panel, nothing when the
+// response has no contract to show
+func (m Model) responseTabsView() string {
+	if m.contract == "" {
+		return ""
+	}
+	var tabs []string
+	for i, name := range []string{"Body", "Contract"} {
+		style := responseTabStyle
+		if i == m.responseTab {
+			style = activeResponseTabStyle
+		}
+		tabs = append(tabs, style.Render(name))
+	}
+	return " " + strings.Join(tabs, "")
+}
+
+// showResponseTab fills the response viewport with the selected tab
+func (m *Model) showResponseTab() {
+	content := m.response
+	if m.responseTab == responseContractTab && m.contract != "" {
+		content = m.contract
+	}
+	m.responseViewport.SetContent(wordwrap.String(content, m.responseViewport.Width))
+	m.responseViewport.GotoTop()
+}
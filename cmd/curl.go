@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// splitShellWords splits a command line the way a POSIX shell would for
+// plain words, single quotes, double quotes and backslash escapes
+func splitShellWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			// A backslash-newline is a line continuation
+			if r != '\n' {
+				word.WriteRune(r)
+				inWord = true
+			}
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// ParseCurl turns a curl command line into a request
+func ParseCurl(command string) (HTTPRequest, error) {
+	var req HTTPRequest
+	words, err := splitShellWords(command)
+	if err != nil {
+		return req, err
+	}
+	if len(words) > 0 && words[0] == "curl" {
+		words = words[1:]
+	}
+
+	headers := map[string]string{}
+	var data []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		next := func() (string, error) {
+			if i+1 >= len(words) {
+				return "", fmt.Errorf("missing value for %s", word)
+			}
+			i++
+			return words[i], nil
+		}
+		switch word {
+		case "-X", "--request":
+			v, err := next()
+			if err != nil {
+				return req, err
+			}
+			req.Method = strings.ToUpper(v)
+		case "-H", "--header":
+			v, err := next()
+			if err != nil {
+				return req, err
+			}
+			key, value, err := ParseHeaderLine(v)
+			if err != nil {
+				return req, err
+			}
+			headers[key] = value
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii", "--json":
+			v, err := next()
+			if err != nil {
+				return req, err
+			}
+			data = append(data, v)
+			if word == "--json" {
+				headers["Content-Type"] = "application/json"
+				headers["Accept"] = "application/json"
+			}
+		case "-u", "--user", "-A", "--user-agent", "-e", "--referer", "-b", "--cookie", "-o", "--output", "-m", "--max-time":
+			v, err := next()
+			if err != nil {
+				return req, err
+			}
+			switch word {
+			case "-A", "--user-agent":
+				headers["User-Agent"] = v
+			case "-e", "--referer":
+				headers["Referer"] = v
+			case "-b", "--cookie":
+				headers["Cookie"] = v
+			}
+		case "--url":
+			v, err := next()
+			if err != nil {
+				return req, err
+			}
+			req.URL = v
+		default:
+			if strings.HasPrefix(word, "-") {
+				// Flags without a value (-s, -L, -k, --compressed...) don't change the request
+				continue
+			}
+			if req.URL == "" {
+				req.URL = word
+			}
+		}
+	}
+	if req.URL == "" {
+		return req, fmt.Errorf("no URL found in the curl command")
+	}
+
+	req.Body = strings.Join(data, "&")
+	if req.Method == "" {
+		req.Method = "GET"
+		if req.Body != "" {
+			req.Method = "POST"
+		}
+	}
+	if len(headers) > 0 {
+		b, err := json.MarshalIndent(headers, "", "  ")
+		if err != nil {
+			return req, err
+		}
+		req.Headers = string(b)
+	}
+	req.Name = requestNameFromURL(req.Method, req.URL)
+	return req, nil
+}
+
+func requestNameFromURL(method, rawURL string) string {
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" && u.Path != "/" {
+		name = path.Base(u.Path)
+	} else if err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return strings.ToLower(method) + " " + name
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ToCurl renders a request as a curl command line
+func ToCurl(req HTTPRequest) string {
+	var sb strings.Builder
+	sb.WriteString("curl")
+	method := strings.ToUpper(req.Method)
+	if method != "GET" {
+		sb.WriteString(" -X " + method)
+	}
+	sb.WriteString(" " + shellQuote(req.URL))
+	for _, line := range HeaderLines(req.Headers) {
+		sb.WriteString(" \\\n  -H " + shellQuote(line))
+	}
+	if strings.TrimSpace(req.Body) != "" {
+		sb.WriteString(" \\\n  --data-raw " + shellQuote(req.Body))
+	}
+	return sb.String()
+}
+
+// exportCurl copies the request in the fields to the clipboard as a curl
+// command, resolved with the environment and its secrets masked as in
+// "postbear export"
+func (m *Model) exportCurl() {
+	vars, _ := ResolveVariables(m.filepath, m.environment)
+	command := MaskSecrets(ToCurl(ResolveRequest(m.editedRequest(), vars)))
+	if err := clipboard.WriteAll(command); err != nil {
+		m.message = m.appBoundaryMessage("Could not copy the curl command: " + err.Error())
+		return
+	}
+	m.message = m.appBoundaryMessage("Copied the request as a curl command")
+}
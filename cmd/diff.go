@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffIgnoreVar is the global variable listing the fields the diff ignores,
+// separated by commas
+const diffIgnoreVar = "diffIgnore"
+
+// Headers that change on every response
+var volatileHeaders = []string{"Date", "Age", "Content-Length", "Expires", "Etag", "Last-Modified", "X-Request-Id", "Cf-Ray", "Set-Cookie"}
+
+// ResponseRecord is what a diff compares of a response
+type ResponseRecord struct {
+	Label   string // where the response comes from, e.g. the environment
+	Status  string
+	Headers map[string]string
+	Body    string
+}
+
+func recordFromHTTP(label string, resp *http.Response, body []byte) ResponseRecord {
+	return ResponseRecord{
+		Label:   label,
+		Status:  strconv.Itoa(resp.StatusCode),
+		Headers: flattenHeader(resp.Header),
+		Body:    string(body),
+	}
+}
+
+// Kinds of difference
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// DiffEntry is one difference between two responses
+type DiffEntry struct {
+	Path string `json:"path"` // JSON path of the field, or header name
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// ResponseDiff is the difference between two responses
+type ResponseDiff struct {
+	From, To ResponseRecord
+	Status   bool // the statuses differ
+	Headers  []DiffEntry
+	Body     []DiffEntry
+	Text     []string // line diff of bodies that are not JSON, "+ " / "- " / "  " prefixed
+}
+
+// Empty reports whether both responses are the same
+func (d ResponseDiff) Empty() bool {
+	return !d.Status && len(d.Headers) == 0 && len(d.Body) == 0 && len(d.Text) == 0
+}
+
+// ParseIgnoreRules splits a comma separated list of ignore rules
+func ParseIgnoreRules(list string) []string {
+	var rules []string
+	for _, rule := range strings.Split(list, ",") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ignored matches a JSON path like "data.0.createdAt" against the rules. A
+// rule without dots matches the field name anywhere, otherwise it matches
+// the whole path with * standing for one segment, e.g. "data.*.id".
+func ignored(fieldPath string, rules []string) bool {
+	name := fieldPath
+	if i := strings.LastIndex(fieldPath, "."); i != -1 {
+		name = fieldPath[i+1:]
+	}
+	for _, rule := range rules {
+		rule = strings.TrimPrefix(rule, "$.")
+		if !strings.Contains(rule, ".") {
+			if ok, _ := path.Match(rule, name); ok {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(strings.ReplaceAll(rule, ".", "/"), strings.ReplaceAll(fieldPath, ".", "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffResponses compares two responses, skipping the fields and headers
+// matched by the ignore rules
+func DiffResponses(from, to ResponseRecord, rules []string) ResponseDiff {
+	d := ResponseDiff{From: from, To: to, Status: from.Status != to.Status}
+	d.Headers = diffHeaders(from.Headers, to.Headers, append(append([]string{}, volatileHeaders...), rules...))
+
+	var a, b interface{}
+	errA := json.Unmarshal([]byte(from.Body), &a)
+	errB := json.Unmarshal([]byte(to.Body), &b)
+	if errA == nil && errB == nil {
+		d.Body = DiffJSON(a, b, rules)
+	} else if from.Body != to.Body {
+		d.Text = diffLines(strings.Split(from.Body, "\n"), strings.Split(to.Body, "\n"))
+	}
+	return d
+}
+
+func diffHeaders(a, b map[string]string, rules []string) []DiffEntry {
+	skip := func(name string) bool {
+		for _, rule := range rules {
+			if strings.EqualFold(rule, name) {
+				return true
+			}
+		}
+		return false
+	}
+	var entries []DiffEntry
+	for name, old := range a {
+		if skip(name) {
+			continue
+		}
+		if value, ok := b[name]; !ok {
+			entries = append(entries, DiffEntry{Path: name, Kind: DiffRemoved, Old: old})
+		} else if value != old {
+			entries = append(entries, DiffEntry{Path: name, Kind: DiffChanged, Old: old, New: value})
+		}
+	}
+	for name, value := range b {
+		if _, ok := a[name]; !ok && !skip(name) {
+			entries = append(entries, DiffEntry{Path: name, Kind: DiffAdded, New: value})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// DiffJSON compares two decoded JSON documents field by field
+func DiffJSON(a, b interface{}, rules []string) []DiffEntry {
+	var entries []DiffEntry
+	diffValue("", a, b, rules, &entries)
+	return entries
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func jsonText(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func diffValue(at string, a, b interface{}, rules []string, entries *[]DiffEntry) {
+	if at != "" && ignored(at, rules) {
+		return
+	}
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p := joinPath(at, k)
+			old, inA := av[k]
+			value, inB := bv[k]
+			switch {
+			case ignored(p, rules):
+			case !inB:
+				*entries = append(*entries, DiffEntry{Path: p, Kind: DiffRemoved, Old: jsonText(old)})
+			case !inA:
+				*entries = append(*entries, DiffEntry{Path: p, Kind: DiffAdded, New: jsonText(value)})
+			default:
+				diffValue(p, old, value, rules, entries)
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			p := joinPath(at, strconv.Itoa(i))
+			switch {
+			case ignored(p, rules):
+			case i >= len(bv):
+				*entries = append(*entries, DiffEntry{Path: p, Kind: DiffRemoved, Old: jsonText(av[i])})
+			case i >= len(av):
+				*entries = append(*entries, DiffEntry{Path: p, Kind: DiffAdded, New: jsonText(bv[i])})
+			default:
+				diffValue(p, av[i], bv[i], rules, entries)
+			}
+		}
+		return
+	}
+	if jsonText(a) != jsonText(b) {
+		*entries = append(*entries, DiffEntry{Path: at, Kind: DiffChanged, Old: jsonText(a), New: jsonText(b)})
+	}
+}
+
+// maxLineDiff bounds the size of the line diff, it is quadratic
+const maxLineDiff = 2000
+
+// diffLines is a longest common subsequence diff of two texts
+func diffLines(a, b []string) []string {
+	if len(a) > maxLineDiff || len(b) > maxLineDiff {
+		return []string{fmt.Sprintf("- %d lines", len(a)), fmt.Sprintf("+ %d lines", len(b))}
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+a[i])
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+ "+b[j])
+	}
+	return lines
+}
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(green)
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	diffChangedStyle = lipgloss.NewStyle().Foreground(yellow)
+)
+
+func renderEntries(entries []DiffEntry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		line := e.String()
+		switch e.Kind {
+		case DiffAdded:
+			line = diffAddedStyle.Render(line)
+		case DiffRemoved:
+			line = diffRemovedStyle.Render(line)
+		default:
+			line = diffChangedStyle.Render(line)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// String renders the entry as one plain line
+func (e DiffEntry) String() string {
+	switch e.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", e.Path, e.New)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", e.Path, e.Old)
+	}
+	return fmt.Sprintf("~ %s: %s → %s", e.Path, e.Old, e.New)
+}
+
+// String renders the diff for people
+func (d ResponseDiff) String() string {
+	labelStyle := boldStyle.Foreground(lipgloss.Color("6"))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n%s %s\n\n", diffRemovedStyle.Render("---"), d.From.Label, diffAddedStyle.Render("+++"), d.To.Label))
+	if d.Empty() {
+		sb.WriteString("No differences\n")
+		return sb.String()
+	}
+	if d.Status {
+		sb.WriteString(labelStyle.Render("Status:") + " " + diffChangedStyle.Render(d.From.Status+" → "+d.To.Status) + "\n\n")
+	}
+	if len(d.Headers) > 0 {
+		sb.WriteString(labelStyle.Render("Headers:") + "\n" + renderEntries(d.Headers) + "\n")
+	}
+	if len(d.Body) > 0 {
+		sb.WriteString(labelStyle.Render("Body:") + "\n" + renderEntries(d.Body))
+	}
+	if len(d.Text) > 0 {
+		sb.WriteString(labelStyle.Render("Body:") + "\n")
+		for _, line := range d.Text {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				line = diffAddedStyle.Render(line)
+			case strings.HasPrefix(line, "-"):
+				line = diffRemovedStyle.Render(line)
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// FetchResponse sends a request, its variables already resolved, and
+// records its response
+func FetchResponse(req HTTPRequest, label string, timeout time.Duration) (ResponseRecord, error) {
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequest(strings.ToUpper(req.Method), req.URL, body)
+	if err != nil {
+		return ResponseRecord{}, err
+	}
+	for _, line := range HeaderLines(req.Headers) {
+		if key, value, err := ParseHeaderLine(line); err == nil {
+			httpReq.Header.Set(key, value)
+		}
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(httpReq)
+	if err != nil {
+		return ResponseRecord{}, NetworkError{err}
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ResponseRecord{}, NetworkError{err}
+	}
+	return recordFromHTTP(label, resp, content), nil
+}
+
+// DiffEnvironments sends a request of a .http file with two environments
+// and compares the responses, ignoring the fields of the diffIgnore
+// global variable and of rules
+func DiffEnvironments(file string, sel RequestSelector, fromEnv, toEnv string, rules []string, timeout time.Duration) (ResponseDiff, error) {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return ResponseDiff{}, err
+	}
+	requests, err := SelectRequests(data, sel)
+	if err != nil {
+		return ResponseDiff{}, err
+	}
+	if len(requests) != 1 {
+		return ResponseDiff{}, fmt.Errorf("pick one request to compare")
+	}
+	var records [2]ResponseRecord
+	for i, env := range []string{fromEnv, toEnv} {
+		vars, err := ResolveVariables(file, env)
+		if err != nil {
+			return ResponseDiff{}, err
+		}
+		label := env
+		if label == "" {
+			label = "no environment"
+		}
+		req := ResolveRequest(requests[0], vars)
+		records[i], err = FetchResponse(req, label+"  "+req.URL, timeout)
+		if err != nil {
+			return ResponseDiff{}, fmt.Errorf("%s: %w", label, err)
+		}
+		if i == 0 {
+			rules = append(rules, ParseIgnoreRules(vars[diffIgnoreVar])...)
+		}
+	}
+	return DiffResponses(records[0], records[1], rules), nil
+}
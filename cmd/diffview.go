@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// responseKey identifies a request across sends
+func responseKey(m Model) string {
+	return m.filepath + "\x00" + m.nameField.Value()
+}
+
+func responseLabel(envName string) string {
+	label := "sent at " + time.Now().Format("15:04:05")
+	if envName != "" {
+		label += " with " + envName
+	}
+	return label
+}
+
+type diffMsg struct {
+	id     int
+	record ResponseRecord
+	err    error
+}
+
+// diffScreen compares the latest response of the request with the previous
+// one, or with the response from another environment
+type diffScreen struct {
+	width       int
+	height      int
+	styles      *Styles
+	returnModel Model
+	viewport    viewport.Model
+	current     ResponseRecord
+	hasCurrent  bool
+	rules       []string
+	envs        []string // environments to compare with
+	envIndex    int      // -1 compares with the previous response
+	id          int
+	loading     bool
+	text        string
+}
+
+func newDiffScreen(m Model) diffScreen {
+	s := diffScreen{
+		width:       m.width,
+		height:      m.height,
+		styles:      m.styles,
+		returnModel: m,
+		viewport:    viewport.New(max(m.width-6, 10), max(m.height-6, 1)),
+		envIndex:    -1,
+	}
+	s.current, s.hasCurrent = m.lastResponses[responseKey(m)]
+	vars, _ := ResolveVariables(m.filepath, m.environment)
+	s.rules = ParseIgnoreRules(vars[diffIgnoreVar])
+	for _, env := range EnvironmentNames(m.filepath) {
+		if env != m.environment {
+			s.envs = append(s.envs, env)
+		}
+	}
+	s.showPrevious()
+	return s
+}
+
+// setText shows text with its secrets masked, the records keep the raw
+// responses so the diff compares them as received
+func (s *diffScreen) setText(text string) {
+	text = MaskSecrets(text)
+	s.text = text
+	s.viewport.SetContent(text)
+	s.viewport.GotoTop()
+}
+
+func (s *diffScreen) showPrevious() {
+	s.envIndex = -1
+	previous, ok := s.returnModel.prevResponses[responseKey(s.returnModel)]
+	switch {
+	case !s.hasCurrent:
+		s.setText("Send the request first, then compare it with its next send or with another environment.")
+	case !ok:
+		s.setText("Send the request again to compare both responses, or press e to compare with another environment.")
+	default:
+		s.setText(DiffResponses(previous, s.current, s.rules).String())
+	}
+}
+
+// compareEnv sends the request with the next environment
+func (s diffScreen) compareEnv() (diffScreen, tea.Cmd) {
+	if !s.hasCurrent {
+		return s, nil
+	}
+	if len(s.envs) == 0 {
+		s.setText("No other environment in " + envFileName)
+		return s, nil
+	}
+	s.envIndex = (s.envIndex + 1) % len(s.envs)
+	s.id++
+	s.loading = true
+	s.setText("Sending the request with " + s.envs[s.envIndex] + "...")
+	m, env, id := s.returnModel, s.envs[s.envIndex], s.id
+	return s, func() tea.Msg {
+		response, status, _, headers := sendByTUI(m, env)
+		if status == "" {
+			return diffMsg{id: id, err: fmt.Errorf("%s", strings.TrimSpace(response))}
+		}
+		return diffMsg{id: id, record: ResponseRecord{
+			Label:   responseLabel(env),
+			Status:  status,
+			Headers: flattenHeader(headers),
+			Body:    response,
+		}}
+	}
+}
+
+func (s diffScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s diffScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		s.viewport.Width = max(s.width-6, 10)
+		s.viewport.Height = max(s.height-6, 1)
+	case diffMsg:
+		if msg.id != s.id {
+			return s, nil
+		}
+		s.loading = false
+		if msg.err != nil {
+			s.setText(codes500Style.Render(" " + msg.err.Error() + " "))
+			return s, nil
+		}
+		s.setText(DiffResponses(s.current, msg.record, s.rules).String())
+		return s, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return s.returnModel.confirmQuit()
+		case "esc":
+			s.returnModel.width = s.width
+			s.returnModel.height = s.height
+			return s.returnModel, s.returnModel.restartWatch()
+		case "e":
+			return s.compareEnv()
+		case "p":
+			s.id++
+			s.loading = false
+			s.showPrevious()
+			return s, nil
+		}
+	}
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+func (s diffScreen) View() string {
+	title := "POSTBEAR Diff  previous send"
+	if s.envIndex >= 0 {
+		title = "POSTBEAR Diff  " + s.envs[s.envIndex]
+	}
+	header := s.appTopLabel(title)
+	content := lipgloss.NewStyle().Padding(0, 1).Render(s.viewport.View())
+	body := borderStyle.Width(max(s.width-2, 1)).Height(max(s.height-4, 1)).Render(content)
+	footer := s.appBottomLabel("p previous send, e next environment, up/down to scroll, <ESC> to go back")
+	return s.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
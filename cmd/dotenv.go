@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dotenvPrefix reads a value of the .env files, as in {{$dotenv API_URL}}
+const dotenvPrefix = "$dotenv "
+
+// dotenvVariablesVar set to true in a file also exposes the .env values as
+// plain {{NAME}} variables, below the environment
+const dotenvVariablesVar = "dotenvVariables"
+
+// LoadDotenv reads the .env file next to the .http file, overridden by
+// .env.<envName> when an environment is selected. Missing files are empty.
+func LoadDotenv(httpFile, envName string) (map[string]string, error) {
+	values := map[string]string{}
+	dir := filepath.Dir(httpFilePath(httpFile))
+	files := []string{".env"}
+	if envName != "" {
+		files = append(files, ".env."+envName)
+	}
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return values, err
+		}
+		err = parseDotenv(f, values)
+		f.Close()
+		if err != nil {
+			return values, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return values, nil
+}
+
+// parseDotenv reads KEY=value lines into values. "export " prefixes, #
+// comments, and single or double quoted values are understood, \n and the
+// like are unescaped in double quotes only.
+func parseDotenv(f *os.File, values map[string]string) error {
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// Anything after the closing quote is a comment
+			unquoted, err := strconv.Unquote(value[:strings.LastIndex(value, `"`)+1])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'") && strings.LastIndex(value, "'") > 0:
+			value = value[1:strings.LastIndex(value, "'")]
+		default:
+			// An unquoted value ends at a comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		values[key] = value
+	}
+	return scanner.Err()
+}
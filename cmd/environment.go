@@ -3,19 +3,30 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 )
 
 var footer string
 
+const (
+	tableHelp = "Ctrl+s save, Ctrl+d delete, Shift+↑/↓ move, Alt+e enable/disable, Ctrl+k secret, Alt+j JSON, Ctrl+t sources, <ESC> back"
+	jsonHelp  = "Ctrl+s save, Ctrl+k move the cursor line to the secret store, Alt+j table, Ctrl+t sources, <ESC> back"
+)
+
 type env struct {
 	width       int
 	height      int
 	styles      *Styles
 	returnModel Model
-	content     textarea.Model
+	table       ParamsTable    // the variables of the file, one row each
+	content     textarea.Model // the enabled variables as raw JSON, the alternate mode
+	jsonMode    bool
+	showSources bool // the saved variables with their sources instead of the editor
 }
 
 func environment(m Model) env {
@@ -27,32 +38,196 @@ func environment(m Model) env {
 		returnModel: m,
 		content:     newTextarea(),
 	}
-	gv := LoadGlobalVarsFromHTTPFile(m.filepath)
-	jsonStr := "{}"
-	if len(gv) > 0 {
-		b, err := json.MarshalIndent(gv, "", "  ")
-		if err == nil {
-			jsonStr = string(b)
-		}
-	}
-
-	env.content.SetValue(jsonStr)
-	env.content.SetWidth(env.width - 2)
-	env.content.SetHeight(env.height - 5)
+	env.load()
+	env.sizeInputs()
 	env.content.Placeholder = `
 	{
 	"Key":"Value",
 }`
 
-	footer = env.appBottomLabel("Ctrl+s to save variables, <ESC> to go back")
+	footer = env.appBottomLabel(tableHelp)
 
 	return env
 
 }
 
+// load fills the table and the JSON editor with the saved variables
+func (en *env) load() {
+	data, _ := LoadHTTPFile(en.returnModel.filepath)
+	en.table = NewVariablesTable()
+	en.table.Validate = validateVariableRow
+	en.table.Rows = nil
+	for _, k := range data.OrderedVars() {
+		value, enabled := data.GlobalVars[k]
+		if !enabled {
+			value = data.DisabledVars[k]
+		}
+		en.appendVariable(k, value, !enabled)
+	}
+	if len(en.table.Rows) == 0 {
+		en.table.AddRow()
+	} else {
+		en.table.focusCell(0, 0)
+	}
+	en.content.SetValue(variablesJSON(data.GlobalVars))
+}
+
+// appendVariable adds a row to the table, the value of a secret is read
+// from the store
+func (en *env) appendVariable(name, value string, disabled bool) {
+	secret := value == SecretReference(name)
+	if secret {
+		value, _ = lookupSecret(name)
+	}
+	en.table.AppendRow(name, value, disabled, secret)
+}
+
+func variablesJSON(vars map[string]string) string {
+	if len(vars) == 0 {
+		return "{}"
+	}
+	b, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// tableVars returns the enabled variables of the table, the secrets as
+// references
+func (en *env) tableVars() map[string]string {
+	vars := map[string]string{}
+	for _, row := range en.table.Rows {
+		k := strings.TrimSpace(row.KeyInput.Value())
+		if k == "" || row.Disabled {
+			continue
+		}
+		vars[k] = strings.TrimSpace(row.ValueInput.Value())
+		if row.Secret {
+			vars[k] = SecretReference(k)
+		}
+	}
+	return vars
+}
+
+// jsonToTable applies the JSON editor to the table: the rows keep their
+// order and flags, the new variables go at the end
+func (en *env) jsonToTable() error {
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(en.content.Value()), &vars); err != nil {
+		return err
+	}
+	rows := en.table.Rows
+	en.table.Rows = nil
+	seen := map[string]bool{}
+	for _, row := range rows {
+		k := strings.TrimSpace(row.KeyInput.Value())
+		v, ok := vars[k]
+		switch {
+		case k == "" || seen[k]:
+			continue
+		case !ok && row.Disabled:
+		case !ok:
+			continue
+		case v == SecretReference(k) && row.Secret:
+			row.Disabled = false
+		default:
+			en.appendVariable(k, v, false)
+			seen[k] = true
+			continue
+		}
+		seen[k] = true
+		en.table.Rows = append(en.table.Rows, row)
+	}
+	for _, k := range sortedKeys(vars) {
+		if !seen[k] {
+			en.appendVariable(k, vars[k], false)
+		}
+	}
+	if len(en.table.Rows) == 0 {
+		en.table.AddRow()
+	} else {
+		en.table.focusCell(0, 0)
+	}
+	return nil
+}
+
+// save writes the table to the .http file, the values of the secrets go to
+// the secret store
+func (en *env) save() string {
+	if en.jsonMode {
+		if err := en.jsonToTable(); err != nil {
+			return "Error: Invalid JSON for environment variables"
+		}
+	}
+	if i := en.table.Invalid(); i >= 0 {
+		return fmt.Sprintf("Error: %s (row %d)", en.table.Validate(&en.table, i), i+1)
+	}
+	// Load all requests from the current .http file (if any)
+	data, _ := LoadHTTPFile(en.returnModel.filepath)
+	data.GlobalVars, data.DisabledVars, data.VarOrder = map[string]string{}, map[string]string{}, nil
+	for _, row := range en.table.Rows {
+		k := strings.TrimSpace(row.KeyInput.Value())
+		if k == "" {
+			continue
+		}
+		v := strings.TrimSpace(row.ValueInput.Value())
+		if row.Secret {
+			// An empty value keeps the stored one
+			if current, ok := lookupSecret(k); v != "" && (!ok || current != v) {
+				if _, err := SetSecret(k, v); err != nil {
+					return "Error: " + err.Error()
+				}
+			}
+			v = SecretReference(k)
+		}
+		if row.Disabled {
+			data.DisabledVars[k] = v
+		} else {
+			data.GlobalVars[k] = v
+		}
+		data.VarOrder = append(data.VarOrder, k)
+	}
+	if err := SaveHTTPFile(data, en.returnModel.filepath); err != nil {
+		return "Error saving .http file"
+	}
+	// Our own write is not an external change
+	if stamp, ok := readStamp(en.returnModel.filepath); ok {
+		en.returnModel.stamps[en.returnModel.filepath] = stamp
+	}
+	en.load()
+	return fmt.Sprintf("Environment variables saved to %s file!", en.returnModel.filepath)
+}
+
+// validateVariableRow checks the name of a variable and the {{references}}
+// of its value
+func validateVariableRow(t *ParamsTable, i int) string {
+	key := strings.TrimSpace(t.Rows[i].KeyInput.Value())
+	value := t.Rows[i].ValueInput.Value()
+	if key == "" {
+		if strings.TrimSpace(value) != "" {
+			return "the name is missing"
+		}
+		return ""
+	}
+	if !variableName.MatchString(key) {
+		return "a name starts with a letter or _, then letters, digits, _, . or -"
+	}
+	for j, row := range t.Rows {
+		if j != i && strings.TrimSpace(row.KeyInput.Value()) == key {
+			return key + " is declared twice"
+		}
+	}
+	if !t.Rows[i].Secret && strings.Count(value, "{{") != strings.Count(value, "}}") {
+		return "a {{ is not closed"
+	}
+	return ""
+}
+
 func (en *env) sizeInputs() {
 	en.content.SetWidth(en.width - 2)
 	en.content.SetHeight(en.height - 5)
+	en.table.width = en.width - 6
 }
 
 func (en env) Init() tea.Cmd {
@@ -63,39 +238,58 @@ func (en env) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
-	en.content.Focus()
-
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" {
-			return en, tea.Quit
+		help := tableHelp
+		if en.jsonMode {
+			help = jsonHelp
 		}
-		if msg.String() == "esc" {
+		switch msg.String() {
+		case "ctrl+c":
+			return en.returnModel.confirmQuit()
+		case "esc":
 			en.returnModel.height = en.height
 			en.returnModel.width = en.width
-			return en.returnModel, nil
-		}
-		if msg.String() == "ctrl+s" {
-			// Save env variables to .http file
-			var globalVars map[string]string
-			err := json.Unmarshal([]byte(en.content.Value()), &globalVars)
-			if err != nil {
-				footer = en.appBottomLabel("Error: Invalid JSON for environment variables")
+			return en.returnModel, en.returnModel.restartWatch()
+		case "ctrl+t":
+			en.showSources = !en.showSources
+			if en.showSources {
+				footer = en.appBottomLabel("Ctrl+t to edit the variables again, <ESC> to go back")
 			} else {
-				// Load all requests from the current .http file (if any)
-				data := &HTTPFileData{GlobalVars: globalVars}
-				if loaded, err := LoadHTTPFile(en.returnModel.filepath); err == nil {
-					data.Requests = loaded.Requests // preserve requests
-				}
-				// Only update the globalVars in the .http file, keep requests unchanged
-				if err := SaveHTTPFile(data, en.returnModel.filepath); err != nil {
-					footer = en.appBottomLabel("Error saving .http file")
-				} else {
-					footer = en.appBottomLabel(fmt.Sprintf("Environment variables saved to %s file!", en.returnModel.filepath))
+				footer = en.appBottomLabel(help)
+			}
+			return en, nil
+		}
+		if en.showSources {
+			return en, nil
+		}
+		switch msg.String() {
+		case "ctrl+s":
+			footer = en.appBottomLabel(en.save())
+			return en, nil
+		case "alt+j":
+			if en.jsonMode {
+				if err := en.jsonToTable(); err != nil {
+					footer = en.appBottomLabel("Error: Invalid JSON for environment variables")
+					return en, nil
 				}
+				footer = en.appBottomLabel(tableHelp)
+			} else {
+				en.content.SetValue(variablesJSON(en.tableVars()))
+				footer = en.appBottomLabel(jsonHelp)
+			}
+			en.jsonMode = !en.jsonMode
+			return en, nil
+		case "ctrl+k":
+			if en.jsonMode {
+				footer = en.appBottomLabel(en.storeSecret())
+				return en, nil
 			}
-		} else {
-			footer = en.appBottomLabel("Ctrl+s to Save, <ESC> to go back")
+		}
+		footer = en.appBottomLabel(help)
+		if !en.jsonMode {
+			en.table.Update(msg, en.width-6)
+			return en, nil
 		}
 
 	case tea.WindowSizeMsg:
@@ -105,6 +299,7 @@ func (en env) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	en.sizeInputs()
 
+	en.content.Focus()
 	en.content, cmd = en.content.Update(msg)
 	cmds = append(cmds, cmd)
 
@@ -115,6 +310,81 @@ func (en env) View() string {
 
 	header := en.appTopLabel("POSTBOY Environment Varibales")
 
-	body := borderStyle.Width(en.width - 2).Height(en.height - 4).Render(en.content.View())
+	content := en.table.View()
+	if en.showSources {
+		content = en.sourcesView()
+	} else if en.jsonMode {
+		content = en.content.View()
+	}
+	body := borderStyle.Width(en.width - 2).Height(en.height - 4).Render(content)
 	return en.styles.Base.Render(header + "\n" + body + "\n" + footer)
 }
+
+// jsonKeyLine is a "key": value line of the variables JSON
+var jsonKeyLine = regexp.MustCompile(`^\s*("(?:[^"\\]|\\.)*")\s*:`)
+
+// storeSecret moves the value of the variable on the cursor line to the
+// secret store, the file keeps a {{$secret name}} reference to it
+func (en *env) storeSecret() string {
+	var globalVars map[string]string
+	if err := json.Unmarshal([]byte(en.content.Value()), &globalVars); err != nil {
+		return "Error: Invalid JSON for environment variables"
+	}
+	lines := strings.Split(en.content.Value(), "\n")
+	if en.content.Line() >= len(lines) {
+		return "Put the cursor on the line of a variable"
+	}
+	match := jsonKeyLine.FindStringSubmatch(lines[en.content.Line()])
+	var name string
+	if match == nil || json.Unmarshal([]byte(match[1]), &name) != nil {
+		return "Put the cursor on the line of a variable"
+	}
+	ref := SecretReference(name)
+	if globalVars[name] == ref {
+		return name + " is already a secret"
+	}
+	store, err := SetSecret(name, globalVars[name])
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	globalVars[name] = ref
+	b, err := json.MarshalIndent(globalVars, "", "  ")
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	en.content.SetValue(string(b))
+	return fmt.Sprintf("%s stored in %s, Ctrl+s to save the file", name, store.Name())
+}
+
+// sourcesView lists the variables the requests of the file see with the
+// selected environment, where each one comes from and what it overrides
+func (en env) sourcesView() string {
+	environment := en.returnModel.environment
+	traces, err := TraceVariables(en.returnModel.filepath, environment)
+	if err != nil {
+		return undefinedVariableStyle.Render(err.Error())
+	}
+	if environment == "" {
+		environment = "none"
+	}
+	var sb strings.Builder
+	sb.WriteString(boldStyle.Render("Environment: ") + environment + "\n\n")
+	nameWidth := 0
+	for _, t := range traces {
+		nameWidth = max(nameWidth, len(t.Name))
+	}
+	for _, t := range traces {
+		source := t.Source
+		if len(t.Overridden) > 0 {
+			source += ", overrides " + strings.Join(t.Overridden, ", ")
+		}
+		value := strings.ReplaceAll(MaskSecrets(t.Value), "\n", " ")
+		if t.Raw != t.Value {
+			value += " ← " + t.Raw
+		}
+		value = ansi.Truncate(value, max(en.width-nameWidth-len(source)-12, 8), "…")
+		sb.WriteString(variableNameStyle.Render(fmt.Sprintf("%-*s", nameWidth, t.Name)) + "  " + value + "  " + variableSourceStyle.Render(source) + "\n")
+	}
+	sb.WriteString("\n" + variableSourceStyle.Render("Saved values only. request > file > environment > .env (with @dotenvVariables = true) > os, {{$dotenv NAME}} reads the .env files"))
+	return sb.String()
+}
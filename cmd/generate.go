@@ -0,0 +1,532 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Directives of the generated requests: the operation they come from, and
+// a hash of the request as generated to tell hand edits apart on sync
+const (
+	operationDirective = "operation"
+	generatedDirective = "generated"
+)
+
+// baseURLVar is the variable the generated requests start with
+const baseURLVar = "baseUrl"
+
+// pathTemplateParam matches the {id} parameters of an OpenAPI path
+var pathTemplateParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// GeneratedFile is a .http file generated from the operations of one tag
+type GeneratedFile struct {
+	Name string // file name
+	Data *HTTPFileData
+}
+
+// SyncStats counts what a sync did to the requests of a generated file
+type SyncStats struct {
+	Added, Updated, Unchanged, Kept, Removed int
+}
+
+func (s SyncStats) String() string {
+	parts := []string{}
+	for _, p := range []struct {
+		n    int
+		what string
+	}{{s.Added, "added"}, {s.Updated, "updated"}, {s.Unchanged, "unchanged"}, {s.Kept, "edited by hand and kept"}, {s.Removed, "removed"}} {
+		if p.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", p.n, p.what))
+		}
+	}
+	if len(parts) == 0 {
+		return "no requests"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GenerateFromOpenAPI builds one .http file per tag with a request for
+// every operation of the spec. specRef, when set, is written as the
+// openapiSpec variable of the files so their responses are validated.
+func GenerateFromOpenAPI(spec *OpenAPISpec, specRef string) []GeneratedFile {
+	byTag := map[string][]HTTPRequest{}
+	names := map[string]map[string]bool{}
+	var order []string
+	for _, tag := range spec.Tags {
+		order = append(order, tag.Name)
+	}
+	for _, op := range spec.Operations() {
+		tag := "default"
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		if _, ok := byTag[tag]; !ok {
+			byTag[tag] = nil
+			names[tag] = map[string]bool{}
+			order = append(order, tag)
+		}
+		req := spec.generateRequest(op)
+		// Names are unique within a file
+		base := req.Name
+		for i := 2; names[tag][req.Name]; i++ {
+			req.Name = fmt.Sprintf("%s %d", base, i)
+		}
+		names[tag][req.Name] = true
+		byTag[tag] = append(byTag[tag], req)
+	}
+
+	var files []GeneratedFile
+	seen := map[string]bool{}
+	for _, tag := range order {
+		if seen[tag] || len(byTag[tag]) == 0 {
+			continue
+		}
+		seen[tag] = true
+		data := &HTTPFileData{Requests: byTag[tag], GlobalVars: map[string]string{}}
+		if specRef != "" {
+			data.GlobalVars[contractSpecVar] = specRef
+		}
+		files = append(files, GeneratedFile{Name: fileSlug(tag) + ".http", Data: data})
+	}
+	return files
+}
+
+// operationKey identifies an operation across syncs
+func operationKey(op *OpenAPIOperation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+	return op.Method + " " + op.Path
+}
+
+func (s *OpenAPISpec) generateRequest(op *OpenAPIOperation) HTTPRequest {
+	req := HTTPRequest{Method: op.Method}
+	switch {
+	case op.Summary != "":
+		req.Name = op.Summary
+	case op.OperationID != "":
+		req.Name = op.OperationID
+	default:
+		req.Name = op.Method + " " + op.Path
+	}
+
+	headers := map[string]string{}
+	query := url.Values{}
+	for _, p := range s.Parameters(op) {
+		if !p.Required || p.In == "path" {
+			continue
+		}
+		value := s.parameterExample(p)
+		switch p.In {
+		case "query":
+			query.Add(p.Name, value)
+		case "header":
+			headers[p.Name] = value
+		}
+	}
+
+	if body := s.RequestBody(op); body != nil {
+		if contentType, media := pickMedia(body.Content); media != nil {
+			headers["Content-Type"] = contentType
+			if strings.Contains(contentType, "json") {
+				if example := s.mediaExample(media); example != nil {
+					b, _ := json.MarshalIndent(example, "", "  ")
+					req.Body = string(b)
+				}
+			}
+		}
+	}
+	// The lowest 2xx response with a body gives the Accept header
+	for _, code := range slices.Sorted(maps.Keys(op.Responses)) {
+		resp := op.Responses[code]
+		if resp != nil && resp.Ref != "" {
+			resp = s.Components.Responses[refName(resp.Ref, "responses")]
+		}
+		if strings.HasPrefix(code, "2") && resp != nil {
+			if contentType, _ := pickMedia(resp.Content); contentType != "" {
+				headers["Accept"] = contentType
+				break
+			}
+		}
+	}
+	s.applySecurity(op, headers, query)
+
+	path := pathTemplateParam.ReplaceAllString(op.Path, "{{$1}}")
+	req.URL = "{{" + baseURLVar + "}}" + path
+	if len(query) > 0 {
+		// Keep the braces of {{variables}} readable
+		encoded := strings.NewReplacer("%7B", "{", "%7D", "}").Replace(query.Encode())
+		req.URL += "?" + encoded
+	}
+	if len(headers) > 0 {
+		b, _ := json.MarshalIndent(headers, "", "  ")
+		req.Headers = string(b)
+	}
+	req.Directives = []string{operationDirective + " " + operationKey(op)}
+	req.Directives = append(req.Directives, generatedDirective+" "+generatedHash(req))
+	return req
+}
+
+// securityVar names the variable holding the credentials of a scheme
+func securityVar(scheme *OpenAPISecurityScheme) string {
+	switch {
+	case scheme.Type == "apiKey":
+		return "apiKey"
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		return "basicAuth"
+	}
+	return "token"
+}
+
+// applySecurity adds the credentials of the first security requirement of
+// an operation as {{variables}}
+func (s *OpenAPISpec) applySecurity(op *OpenAPIOperation, headers map[string]string, query url.Values) {
+	requirements := s.Security
+	if op.Security != nil {
+		requirements = *op.Security
+	}
+	if len(requirements) == 0 {
+		return
+	}
+	names := make([]string, 0, len(requirements[0]))
+	for name := range requirements[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := s.Components.SecuritySchemes[name]
+		if scheme == nil {
+			continue
+		}
+		variable := "{{" + securityVar(scheme) + "}}"
+		switch {
+		case scheme.Type == "apiKey" && scheme.In == "query":
+			query.Set(scheme.Name, variable)
+		case scheme.Type == "apiKey" && scheme.In == "cookie":
+			headers["Cookie"] = scheme.Name + "=" + variable
+		case scheme.Type == "apiKey":
+			headers[scheme.Name] = variable
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			headers["Authorization"] = "Basic " + variable
+		default:
+			// bearer, oauth2 and openIdConnect all send a bearer token
+			headers["Authorization"] = "Bearer " + variable
+		}
+	}
+}
+
+// pickMedia prefers the JSON media type of a content map
+func pickMedia(content map[string]*OpenAPIMediaType) (string, *OpenAPIMediaType) {
+	types := make([]string, 0, len(content))
+	for t := range content {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if strings.Contains(t, "json") {
+			return t, content[t]
+		}
+	}
+	if len(types) > 0 {
+		return types[0], content[types[0]]
+	}
+	return "", nil
+}
+
+func (s *OpenAPISpec) mediaExample(media *OpenAPIMediaType) interface{} {
+	if media.Example != nil {
+		return media.Example
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := media.Examples[name].Value; v != nil {
+			return v
+		}
+	}
+	if media.Schema == nil {
+		return nil
+	}
+	return s.SchemaExample(media.Schema)
+}
+
+// parameterExample is the value written for a required query or header
+// parameter, a {{variable}} when the spec gives none
+func (s *OpenAPISpec) parameterExample(p *OpenAPIParameter) string {
+	value := p.Example
+	if value == nil && p.Schema != nil {
+		schema := s.Schema(p.Schema)
+		switch {
+		case schema == nil:
+		case schema.Example != nil:
+			value = schema.Example
+		case schema.Default != nil:
+			value = schema.Default
+		case len(schema.Enum) > 0:
+			value = schema.Enum[0]
+		}
+	}
+	if value == nil {
+		return "{{" + p.Name + "}}"
+	}
+	return fmt.Sprint(value)
+}
+
+// SchemaExample synthesises a value following a schema, from its examples
+// when it has some
+func (s *OpenAPISpec) SchemaExample(schema *Schema) interface{} {
+	return s.schemaExample(schema, map[string]bool{})
+}
+
+// schemaExample leaves out the schemas already being expanded, refs, so a
+// recursive schema ends
+func (s *OpenAPISpec) schemaExample(schema *Schema, refs map[string]bool) interface{} {
+	if schema != nil && schema.Ref != "" {
+		if refs[schema.Ref] {
+			return nil
+		}
+		refs[schema.Ref] = true
+		defer delete(refs, schema.Ref)
+	}
+	schema = s.Schema(schema)
+	if schema == nil {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := map[string]interface{}{}
+		for _, sub := range schema.AllOf {
+			if obj, ok := s.schemaExample(sub, refs).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		for k, v := range s.objectExample(schema, refs) {
+			merged[k] = v
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return s.schemaExample(schema.OneOf[0], refs)
+	case len(schema.AnyOf) > 0:
+		return s.schemaExample(schema.AnyOf[0], refs)
+	}
+
+	switch {
+	case schema.Type.has("object") || (len(schema.Type) == 0 && len(schema.Properties) > 0):
+		return s.objectExample(schema, refs)
+	case schema.Type.has("array"):
+		if item := s.schemaExample(schema.Items, refs); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case schema.Type.has("integer"), schema.Type.has("number"):
+		if schema.Minimum != nil {
+			return *schema.Minimum
+		}
+		return 0
+	case schema.Type.has("boolean"):
+		return false
+	case schema.Type.has("string"):
+		switch schema.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+func (s *OpenAPISpec) objectExample(schema *Schema, refs map[string]bool) map[string]interface{} {
+	obj := map[string]interface{}{}
+	for name, prop := range schema.Properties {
+		if v := s.schemaExample(prop, refs); v != nil {
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+// generatedHash fingerprints the parts of a request people edit
+func generatedHash(req HTTPRequest) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%s\n%s", req.Name, req.Method, req.URL, strings.Join(HeaderLines(req.Headers), "\n"), strings.TrimSpace(req.Body))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// SyncGenerated updates a generated file with a new generation: requests
+// still as generated are replaced, the ones edited by hand and the ones
+// written by hand are kept, and new operations are added at the end
+func SyncGenerated(existing, generated *HTTPFileData) (*HTTPFileData, SyncStats) {
+	var stats SyncStats
+	fresh := map[string]HTTPRequest{}
+	for _, req := range generated.Requests {
+		op, _ := req.Directive(operationDirective)
+		fresh[op] = req
+	}
+
+	merged := &HTTPFileData{GlobalVars: existing.GlobalVars, DisabledVars: existing.DisabledVars, VarOrder: existing.VarOrder}
+	if merged.GlobalVars == nil {
+		merged.GlobalVars = map[string]string{}
+	}
+	for k, v := range generated.GlobalVars {
+		_, enabled := merged.GlobalVars[k]
+		_, disabled := merged.DisabledVars[k]
+		if !enabled && !disabled {
+			merged.GlobalVars[k] = v
+		}
+	}
+
+	synced := map[string]bool{}
+	for _, req := range existing.Requests {
+		op, ok := req.Directive(operationDirective)
+		if !ok {
+			merged.Requests = append(merged.Requests, req)
+			continue
+		}
+		hash, _ := req.Directive(generatedDirective)
+		next, inSpec := fresh[op]
+		switch {
+		case hash != generatedHash(req):
+			stats.Kept++
+			merged.Requests = append(merged.Requests, req)
+		case !inSpec:
+			stats.Removed++
+			continue
+		case generatedHash(next) == hash:
+			stats.Unchanged++
+			merged.Requests = append(merged.Requests, req)
+		default:
+			stats.Updated++
+			merged.Requests = append(merged.Requests, next)
+		}
+		synced[op] = true
+	}
+	for _, req := range generated.Requests {
+		op, _ := req.Directive(operationDirective)
+		if !synced[op] {
+			stats.Added++
+			merged.Requests = append(merged.Requests, req)
+		}
+	}
+	return merged, stats
+}
+
+// GenerateEnvironments returns an environment per server of the spec with
+// its base URL, and the variables of the path parameters and credentials
+// the generated requests use
+func (s *OpenAPISpec) GenerateEnvironments() (shared, private map[string]map[string]string) {
+	vars := map[string]string{}
+	secrets := map[string]string{}
+	for _, op := range s.Operations() {
+		for _, p := range s.Parameters(op) {
+			if p.In == "path" {
+				example := s.parameterExample(p)
+				if strings.HasPrefix(example, "{{") {
+					example = ""
+				}
+				if _, ok := vars[p.Name]; !ok || vars[p.Name] == "" {
+					vars[p.Name] = example
+				}
+			}
+		}
+	}
+	for _, scheme := range s.Components.SecuritySchemes {
+		if scheme != nil {
+			secrets[securityVar(scheme)] = ""
+		}
+	}
+
+	servers := s.Servers
+	if len(servers) == 0 {
+		servers = []OpenAPIServer{{URL: "http://localhost"}}
+	}
+	shared = map[string]map[string]string{}
+	private = map[string]map[string]string{}
+	for i, server := range servers {
+		name := fileSlug(server.Description)
+		if server.Description == "" {
+			name = "default"
+			if i > 0 {
+				name = fmt.Sprintf("server-%d", i+1)
+			}
+		}
+		env := map[string]string{baseURLVar: strings.TrimSuffix(server.URL, "/")}
+		for k, v := range vars {
+			env[k] = v
+		}
+		shared[name] = env
+		if len(secrets) > 0 {
+			private[name] = secrets
+		}
+	}
+	return shared, private
+}
+
+// MergeEnvironmentFile adds the environments and variables missing from an
+// environment file of dir, the existing values are left alone
+func MergeEnvironmentFile(dir string, private bool, envs map[string]map[string]string) error {
+	path := filepath.Join(dir, envFileName)
+	if private {
+		path = filepath.Join(dir, privateEnvFileName)
+	}
+	content := map[string]map[string]interface{}{}
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(b, &content); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	changed := false
+	for name, vars := range envs {
+		if content[name] == nil {
+			content[name] = map[string]interface{}{}
+		}
+		for k, v := range vars {
+			if _, ok := content[name][k]; !ok {
+				content[name][k] = v
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return nil
+	}
+	out, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0644)
+}
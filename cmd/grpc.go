@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcProtoDirVar is the global variable that points GRPC requests at a local
+// directory of .proto files instead of using server reflection
+const grpcProtoDirVar = "grpcProtoDir"
+
+const grpcTimeout = 30 * time.Second
+
+// Headers that make sense for HTTP requests but are not valid gRPC metadata
+var grpcSkippedHeaders = map[string]bool{
+	"accept":            true,
+	"accept-encoding":   true,
+	"connection":        true,
+	"content-length":    true,
+	"content-type":      true,
+	"host":              true,
+	"keep-alive":        true,
+	"te":                true,
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"user-agent":        true,
+}
+
+// grpcTarget is a parsed GRPC request line: [grpc://|grpcs://]host/Service/Method
+type grpcTarget struct {
+	host    string
+	service string
+	method  string
+	secure  bool
+}
+
+func parseGRPCTarget(raw string) (grpcTarget, error) {
+	var t grpcTarget
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "grpcs://"):
+		t.secure = true
+		raw = strings.TrimPrefix(raw, "grpcs://")
+	case strings.HasPrefix(raw, "grpc://"):
+		raw = strings.TrimPrefix(raw, "grpc://")
+	}
+	parts := strings.Split(raw, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return t, fmt.Errorf("expected host/Service/Method, got %q", raw)
+	}
+	t.host, t.service, t.method = parts[0], parts[1], parts[2]
+	return t, nil
+}
+
+// grpcCode is the code named as in the status returned by sendGRPC, the
+// errors that never reached the server are Unknown
+func grpcCode(name string) codes.Code {
+	name = strings.TrimSpace(name)
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c
+		}
+	}
+	return codes.Unknown
+}
+
+func (t grpcTarget) fullMethod() string {
+	return "/" + t.service + "/" + t.method
+}
+
+// sendGRPC performs a GRPC request and returns the response as JSON,
+// the gRPC status code and the elapsed time, mirroring sendByTUI
+func sendGRPC(target string, headersJSON string, body string, variables map[string]string) (string, string, string) {
+	t, err := parseGRPCTarget(target)
+	if err != nil {
+		return " \n Error parsing gRPC target \n\n " + err.Error(), " Incorrect Endpoint ", ""
+	}
+
+	md := metadata.MD{}
+	if strings.TrimSpace(headersJSON) != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+			return " \n Error parsing Headers \n\n Correct the Headers format", " Incorrect Headers ", ""
+		}
+		for key, value := range headers {
+			if grpcSkippedHeaders[strings.ToLower(key)] {
+				continue
+			}
+			md.Append(key, value)
+		}
+	}
+
+	creds := insecure.NewCredentials()
+	if t.secure {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.NewClient(t.host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return "Failed to make request\n\n" + err.Error(), "", ""
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), grpcTimeout)
+	defer cancel()
+
+	var methodDesc protoreflect.MethodDescriptor
+	if dir := variables[grpcProtoDirVar]; dir != "" {
+		methodDesc, err = methodFromProtoDir(ctx, dir, t)
+	} else {
+		methodDesc, err = methodFromReflection(ctx, conn, t)
+	}
+	if err != nil {
+		return "Failed to resolve gRPC method\n\n" + err.Error(), "", ""
+	}
+	if methodDesc.IsStreamingClient() {
+		return "Client streaming methods are not supported", "", ""
+	}
+
+	req := dynamicpb.NewMessage(methodDesc.Input())
+	if strings.TrimSpace(body) != "" {
+		if err := protojson.Unmarshal([]byte(body), req); err != nil {
+			return " \n Error parsing Body \n\n " + err.Error(), " Incorrect Body ", ""
+		}
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	startTime := time.Now()
+
+	var messages []proto.Message
+	if methodDesc.IsStreamingServer() {
+		messages, err = grpcServerStream(ctx, conn, t, methodDesc, req)
+	} else {
+		resp := dynamicpb.NewMessage(methodDesc.Output())
+		if err = conn.Invoke(ctx, t.fullMethod(), req, resp); err == nil {
+			messages = []proto.Message{resp}
+		}
+	}
+	ms := time.Since(startTime).Milliseconds()
+
+	// A stream that fails midway still shows the messages received so far
+	if err != nil && len(messages) == 0 {
+		st := status.Convert(err)
+		return st.Message(), st.Code().String(), fmt.Sprintf(" %vms ", ms)
+	}
+	code := status.Code(err).String()
+
+	out, err := grpcMessagesToJSON(messages, methodDesc.IsStreamingServer())
+	if err != nil {
+		return "Failed to read response\n\n" + err.Error(), "", ""
+	}
+	return out, code, fmt.Sprintf(" %vms ", ms)
+}
+
+func grpcServerStream(ctx context.Context, conn *grpc.ClientConn, t grpcTarget, methodDesc protoreflect.MethodDescriptor, req proto.Message) ([]proto.Message, error) {
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, t.fullMethod())
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var messages []proto.Message
+	for {
+		resp := dynamicpb.NewMessage(methodDesc.Output())
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, resp)
+	}
+}
+
+// grpcMessagesToJSON renders a unary response as a JSON object and a
+// server stream as a JSON array of its messages
+func grpcMessagesToJSON(messages []proto.Message, streaming bool) (string, error) {
+	opts := protojson.MarshalOptions{EmitUnpopulated: true}
+	var parts []string
+	for _, msg := range messages {
+		b, err := opts.Marshal(msg)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(b))
+	}
+	if !streaming && len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "[" + strings.Join(parts, ",") + "]", nil
+}
+
+// methodFromProtoDir compiles every .proto file under dir and looks up the method
+func methodFromProtoDir(ctx context.Context, dir string, t grpcTarget) (protoreflect.MethodDescriptor, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".proto") {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .proto files found in %s", dir)
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{dir}}),
+	}
+	compiled, err := compiler.Compile(ctx, files...)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := compiled.AsResolver().FindDescriptorByName(protoreflect.FullName(t.service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found in %s", t.service, dir)
+	}
+	return findMethod(desc, t)
+}
+
+// methodFromReflection asks the server for the file descriptors that define
+// the service, including their dependencies, and looks up the method
+func methodFromReflection(ctx context.Context, conn *grpc.ClientConn, t grpcTarget) (protoreflect.MethodDescriptor, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	fdps := map[string]*descriptorpb.FileDescriptorProto{}
+	addFiles := func(req *reflectionpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return fmt.Errorf("reflection: %s", errResp.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fdp); err != nil {
+				return err
+			}
+			fdps[fdp.GetName()] = fdp
+		}
+		return nil
+	}
+
+	err = addFiles(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: t.service},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Servers usually send the dependencies along, fetch any that are missing
+	for missing := missingDependencies(fdps); len(missing) > 0; missing = missingDependencies(fdps) {
+		for _, name := range missing {
+			err := addFiles(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := fdps[name]; !ok {
+				return nil, fmt.Errorf("reflection: server did not return %s", name)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fdp := range fdps {
+		set.File = append(set.File, fdp)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := registry.FindDescriptorByName(protoreflect.FullName(t.service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found on server", t.service)
+	}
+	return findMethod(desc, t)
+}
+
+func missingDependencies(fdps map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	for _, fdp := range fdps {
+		for _, dep := range fdp.GetDependency() {
+			if _, ok := fdps[dep]; !ok {
+				missing = append(missing, dep)
+			}
+		}
+	}
+	return missing
+}
+
+func findMethod(desc protoreflect.Descriptor, t grpcTarget) (protoreflect.MethodDescriptor, error) {
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", t.service)
+	}
+	method := service.Methods().ByName(protoreflect.Name(t.method))
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found in %s", t.method, t.service)
+	}
+	return method, nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestParseGRPCTarget(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want grpcTarget
+	}{
+		{"localhost:50051/pkg.Greeter/SayHello", grpcTarget{host: "localhost:50051", service: "pkg.Greeter", method: "SayHello"}},
+		{"grpc://localhost:50051/pkg.Greeter/SayHello", grpcTarget{host: "localhost:50051", service: "pkg.Greeter", method: "SayHello"}},
+		{"grpcs://api.example.com:443/pkg.Greeter/SayHello", grpcTarget{host: "api.example.com:443", service: "pkg.Greeter", method: "SayHello", secure: true}},
+		{"  localhost:50051/pkg.Greeter/SayHello ", grpcTarget{host: "localhost:50051", service: "pkg.Greeter", method: "SayHello"}},
+	}
+	for _, tt := range tests {
+		got, err := parseGRPCTarget(tt.raw)
+		if err != nil {
+			t.Errorf("parseGRPCTarget(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseGRPCTarget(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+		if got.fullMethod() != "/pkg.Greeter/SayHello" {
+			t.Errorf("fullMethod() = %q", got.fullMethod())
+		}
+	}
+
+	for _, raw := range []string{"", "localhost:50051", "localhost:50051/pkg.Greeter", "localhost:50051//SayHello", "grpc://localhost/a/b/c"} {
+		if _, err := parseGRPCTarget(raw); err == nil {
+			t.Errorf("parseGRPCTarget(%q) accepted an invalid target", raw)
+		}
+	}
+}
+
+// startHealthServer serves the health service, with reflection, on a
+// random local port
+func startHealthServer(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("postbear", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestSendGRPCReflection(t *testing.T) {
+	addr := startHealthServer(t)
+
+	response, code, elapsed := sendGRPC(addr+"/grpc.health.v1.Health/Check", `{"x-trace": "1"}`, `{"service": "postbear"}`, nil)
+	if code != "OK" {
+		t.Fatalf("code = %q, response %q", code, response)
+	}
+	if elapsed == "" {
+		t.Error("no elapsed time")
+	}
+	var got struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(response), &got); err != nil {
+		t.Fatalf("response is not JSON: %q", response)
+	}
+	if got.Status != "SERVING" {
+		t.Errorf("status = %q, want SERVING", got.Status)
+	}
+
+	// Errors of the server come back with their code
+	response, code, _ = sendGRPC(addr+"/grpc.health.v1.Health/Check", "", `{"service": "unknown"}`, nil)
+	if code != "NotFound" {
+		t.Errorf("code = %q, want NotFound, response %q", code, response)
+	}
+	if grpcCode(code) != codes.NotFound {
+		t.Errorf("grpcCode(%q) = %v, want NotFound", code, grpcCode(code))
+	}
+
+	// Methods the server does not describe are not sent
+	if _, code, _ := sendGRPC(addr+"/grpc.health.v1.Health/Nope", "", "", nil); code != "" {
+		t.Errorf("code = %q for an unknown method", code)
+	}
+	if _, code, _ := sendGRPC(addr+"/grpc.health.v1.Health/Check", "", `{"nope": 1}`, nil); code != " Incorrect Body " {
+		t.Errorf("code = %q for a body with an unknown field", code)
+	}
+}
+
+func TestSendHTTPRequestGRPCInvalid(t *testing.T) {
+	addr := startHealthServer(t)
+
+	req := HTTPRequest{Name: "check", Method: "GRPC", URL: addr + "/grpc.health.v1.Health/Check", Body: `{"nope": 1}`}
+	err := sendHTTPRequest(req, nil, CLIOptions{Output: OutputRaw})
+	var invalid InvalidRequestError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("err = %v, want an InvalidRequestError", err)
+	}
+	if msg := err.Error(); msg == "" || msg[0] == ' ' {
+		t.Errorf("message %q", msg)
+	}
+
+	req.Body, req.Headers = "", "not json"
+	if err := sendHTTPRequest(req, nil, CLIOptions{}); !errors.As(err, &invalid) {
+		t.Errorf("err = %v for invalid headers, want an InvalidRequestError", err)
+	}
+}
@@ -11,16 +11,47 @@ import (
 const commandsContent = `tab = Move Around
 shift + tab = Reverse Tab								
 n = New Request (in requests list panel)
-r = Remove Request (in requests list panel)
-enter = Send Request
-ctrl + s = Save Requests in a .http file
-shift + Arrow Keys = Change Tabs (Params/Body/Header)
-enter = Move from key input to value input (in Params tab)
-enter = Add a new row from value input (in Params tab)
-key up / key down = move around params (in Params tab)
+r = Remove Request, or all marked requests (in requests list panel)
+d = Duplicate Request (in requests list panel)
+e / F2 = Rename Request inline (in requests list panel)
+space = Mark Request for bulk remove/move (in requests list panel)
+shift + up / shift + down = Move Request, or all marked requests (in requests list panel)
+u = Undo last remove/move/rename/duplicate (in requests list panel)
+mouse drag = Move Request (in requests list panel)
+enter = Send Request (twice when it uses undefined variables)
+enter = Collapse/Expand folder or file (in requests list panel)
+ctrl + s = Save Requests in a .http file (twice to overwrite a file changed on disk)
+alt + s = Toggle Autosave (or start with POSTBEAR_AUTOSAVE=1)
+alt + r = Reload a .http file changed on disk, dropping local edits
+alt + m = Merge a .http file changed on disk with local edits
+shift + Arrow Keys = Change Tabs (Params/Body/Headers/Variables)
+shift + left / shift + right = Switch between the Body and Contract tabs (in response panel)
+enter = Move from key to value to description input (in Params tab)
+enter = Add a new row from description input (in Params tab)
+key up / key down = move around params and path variables (in Params tab)
+alt + e = Enable/Disable the param, a disabled one stays in the table but not in the URL (in Params tab)
+ctrl + d = Delete the param (in Params tab)
+shift + up / shift + down = Move the param (in Params tab)
+alt + b = Bulk edit the params as key=value lines (in Params tab)
+alt + f = Prettify the JSON, XML or GraphQL (in Body and Headers tabs)
+alt + c = Minify the JSON, XML or GraphQL (in Body and Headers tabs)
 ctrl + e = Open Environment Variables page
+ctrl + k = Make the variable of the cursor secret, its value goes to the secret store (in Environment Variables page)
+alt + e = Enable/Disable the variable of the cursor (in Environment Variables page)
+ctrl + d = Delete the variable of the cursor (in Environment Variables page)
+shift + up / shift + down = Move the variable of the cursor (in Environment Variables page)
+alt + j = Switch between the table and the raw JSON editor (in Environment Variables page)
+ctrl + t = Show where each variable comes from (in Environment Variables page)
+alt + u = Enter the passphrase of the secrets file, when there is no OS keyring
+alt + n = Switch the environment used to resolve the variables
+alt + x = Copy the request as a curl command
+ctrl + p = Quick open a request (fuzzy search by name, method and URL)
+ctrl + o = Open Command Palette
+ctrl + r = Run the requests of the file, or the marked ones, in order (optionally once per row of a CSV/JSON data file)
+alt + d = Diff the response with the previous one, or with another environment
+ctrl + b = Benchmark the request (500 requests, 10 workers)
 ctrl + h = Open Help page
-ctrl + c = Quit`
+ctrl + c = Quit (twice when there are unsaved changes)`
 
 func createCommandRows() []table.Row {
 	lines := strings.Split(commandsContent, "\n")
@@ -95,9 +126,9 @@ func (m help) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc":
 			m.returnModel.height = m.height
 			m.returnModel.width = m.width
-			return m.returnModel, nil
+			return m.returnModel, m.returnModel.restartWatch()
 		case "ctrl+c":
-			return m, tea.Quit
+			return m.returnModel.confirmQuit()
 		}
 	}
 	return m, nil
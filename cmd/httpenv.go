@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Environment files follow the http-client convention: a JSON object of
+// environments, each one an object of variables. The private file is meant
+// to stay out of version control and overrides the shared one.
+const (
+	envFileName        = "http-client.env.json"
+	privateEnvFileName = "http-client.private.env.json"
+)
+
+func loadEnvFile(path string) (map[string]map[string]interface{}, error) {
+	envs := map[string]map[string]interface{}{}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return envs, nil
+	}
+	if err != nil {
+		return envs, err
+	}
+	if err := json.Unmarshal(content, &envs); err != nil {
+		return envs, fmt.Errorf("%s: %w", path, err)
+	}
+	return envs, nil
+}
+
+// EnvironmentNames lists the environments declared next to the .http file
+func EnvironmentNames(httpFile string) []string {
+	dir := filepath.Dir(httpFilePath(httpFile))
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range []string{envFileName, privateEnvFileName} {
+		envs, _ := loadEnvFile(filepath.Join(dir, name))
+		for env := range envs {
+			if !seen[env] {
+				seen[env] = true
+				names = append(names, env)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadEnvironment returns the variables of the named environment declared
+// next to the .http file
+func LoadEnvironment(httpFile, name string) (map[string]string, error) {
+	vars := map[string]string{}
+	if name == "" {
+		return vars, nil
+	}
+	dir := filepath.Dir(httpFilePath(httpFile))
+	found := false
+	for _, file := range []string{envFileName, privateEnvFileName} {
+		envs, err := loadEnvFile(filepath.Join(dir, file))
+		if err != nil {
+			return vars, err
+		}
+		env, ok := envs[name]
+		if !ok {
+			continue
+		}
+		found = true
+		for k, v := range env {
+			vars[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	if !found {
+		return vars, fmt.Errorf("environment %q not found in %s", name, filepath.Join(dir, envFileName))
+	}
+	return vars, nil
+}
+
+// ResolveVariables returns the variables available to the requests of a
+// .http file: the selected environment, overridden by the file variables,
+// their {{references}} to each other expanded
+func ResolveVariables(httpFile, envName string) (map[string]string, error) {
+	scope, err := ScopedVariables(httpFile, envName)
+	return expandVariables(scopeValues(scope)), err
+}
@@ -5,39 +5,106 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
 type HTTPRequest struct {
-	Name    string
-	Method  string
-	URL     string
-	Headers string
-	Body    string
-	Params  string
+	Name     string
+	Method   string
+	URL      string
+	Headers  string
+	Body     string
+	Params   string
+	Response string // example response served by the mock server, from its "HTTP/1.1 200" status line on
+	// Directives are the "# @name value" comments written after the request
+	// line, e.g. "snapshotIgnore id, createdAt"
+	Directives []string
+	Vars       map[string]string // "@name = value" declarations of the request, winning over the file ones
 }
 
+// Directive returns the value of the named directive of the request
+func (r HTTPRequest) Directive(name string) (string, bool) {
+	for _, d := range r.Directives {
+		key, value, _ := strings.Cut(d, " ")
+		if key == name {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// responseStatusLine starts the example response of a request, e.g.
+// "HTTP/1.1 201 Created" or "HTTP 404"
+var responseStatusLine = regexp.MustCompile(`^HTTP(/[0-9.]+)?\s+[0-9]{3}\b`)
+
+// fileBanner is the first line of the .http files written by postbear
+const fileBanner = "### ||| POSTBEAR |||"
+
 type HTTPFileData struct {
 	Requests   []HTTPRequest
 	GlobalVars map[string]string
+	// DisabledVars are the global variables commented out as
+	// "# @name = value", kept in the file but not resolved
+	DisabledVars map[string]string
+	// VarOrder is the order the global variables are written in, the ones
+	// missing from it go after, sorted
+	VarOrder []string
+}
+
+// OrderedVars lists the names of the global variables, enabled or not, in
+// the order they are written
+func (h *HTTPFileData) OrderedVars() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, k := range h.VarOrder {
+		_, enabled := h.GlobalVars[k]
+		_, disabled := h.DisabledVars[k]
+		if (enabled || disabled) && !seen[k] {
+			seen[k] = true
+			names = append(names, k)
+		}
+	}
+	var rest []string
+	for _, vars := range []map[string]string{h.GlobalVars, h.DisabledVars} {
+		for k := range vars {
+			if !seen[k] {
+				seen[k] = true
+				rest = append(rest, k)
+			}
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
 }
 
 // Serialize HTTPFileData to .http file format
 func (h *HTTPFileData) ToHTTPFileFormat() string {
 	var sb strings.Builder
-	sb.WriteString("### ||| POSTBEAR |||\n")
+	sb.WriteString(fileBanner + "\n")
 	// Write global variables
-	if len(h.GlobalVars) > 0 {
+	if len(h.GlobalVars)+len(h.DisabledVars) > 0 {
 		sb.WriteString("### Global Variables\n")
-		for k, v := range h.GlobalVars {
-			sb.WriteString(fmt.Sprintf("@%s = %s\n", k, v))
+		for _, k := range h.OrderedVars() {
+			if v, ok := h.GlobalVars[k]; ok {
+				sb.WriteString(fmt.Sprintf("@%s = %s\n", k, v))
+			} else {
+				sb.WriteString(fmt.Sprintf("# @%s = %s\n", k, h.DisabledVars[k]))
+			}
 		}
 		sb.WriteString("\n")
 	}
 	// Write requests
 	for _, req := range h.Requests {
 		sb.WriteString(fmt.Sprintf("### %s\n", req.Name))
+		for _, k := range sortedKeys(req.Vars) {
+			sb.WriteString(fmt.Sprintf("@%s = %s\n", k, req.Vars[k]))
+		}
 		sb.WriteString(fmt.Sprintf("%s %s\n", req.Method, req.URL))
+		for _, d := range req.Directives {
+			sb.WriteString("# @" + d + "\n")
+		}
 		// Write headers as HeaderName: Value per line
 		if req.Headers != "" {
 			headers, _ := parseHeadersToMap(req.Headers)
@@ -48,6 +115,9 @@ func (h *HTTPFileData) ToHTTPFileFormat() string {
 		if req.Body != "" {
 			sb.WriteString("\n" + req.Body + "\n")
 		}
+		if req.Response != "" {
+			sb.WriteString("\n" + req.Response + "\n")
+		}
 		sb.WriteString("\n")
 	}
 	return sb.String()
@@ -79,66 +149,68 @@ func parseHeadersToMap(headers string) (map[string]string, error) {
 	return stringMap, nil
 }
 
-// Save HTTPFileData to a .http file in the current working directory
-func SaveHTTPFile(data *HTTPFileData, filename string) error {
-	if !strings.HasSuffix(filename, ".http") {
-		filename += ".http"
+// httpFilePath resolves filename against the current working directory
+func httpFilePath(filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
 	}
 	cwd, err := os.Getwd()
 	if err != nil {
-		return err
+		return filename
+	}
+	return filepath.Join(cwd, filename)
+}
+
+// Save HTTPFileData to a .http file in the current working directory
+func SaveHTTPFile(data *HTTPFileData, filename string) error {
+	if !isHTTPFile(filename) {
+		filename += ".http"
 	}
-	path := filepath.Join(cwd, filename)
 	content := data.ToHTTPFileFormat()
-	return os.WriteFile(path, []byte(content), 0644)
+	return os.WriteFile(httpFilePath(filename), []byte(content), 0644)
 }
 
-// LoadGlobalVarsFromHTTPFile loads global variables from the postbear.http file
+// LoadGlobalVarsFromHTTPFile loads the file variables of a .http file
 func LoadGlobalVarsFromHTTPFile(filename string) map[string]string {
-	vars := make(map[string]string)
-	cwd, err := os.Getwd()
-	if err != nil {
-		return vars
+	data, _ := LoadHTTPFile(filename)
+	return data.GlobalVars
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-	path := filepath.Join(cwd, filename)
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return vars
+	sort.Strings(keys)
+	return keys
+}
+
+// trailingDeclarations reports whether lines start with a declaration and
+// hold only declarations, comments and blank lines up to the next ### line
+func trailingDeclarations(lines []string) bool {
+	if _, _, ok := parseVariableLine(lines[0]); !ok {
+		return false
 	}
-	lines := strings.Split(string(content), "\n")
-	inGlobals := false
-	for _, line := range lines {
-		if strings.HasPrefix(line, "### Global Variables") {
-			inGlobals = true
-			continue
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "### ") {
+			return true
 		}
-		if inGlobals {
-			if strings.HasPrefix(line, "### ") && !strings.HasPrefix(line, "### Global Variables") {
-				break
-			}
-			if strings.HasPrefix(line, "@") {
-				parts := strings.SplitN(line[1:], " = ", 2)
-				if len(parts) == 2 {
-					vars[parts[0]] = parts[1]
-				}
-			}
+		if _, _, ok := parseVariableLine(line); !ok && line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "//") {
+			return false
 		}
 	}
-	return vars
+	return true
 }
 
 // LoadHTTPFile loads the HTTPFileData (requests and global vars) from a .http file
 func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 	data := &HTTPFileData{
-		Requests:   []HTTPRequest{},
-		GlobalVars: map[string]string{},
+		Requests:     []HTTPRequest{},
+		GlobalVars:   map[string]string{},
+		DisabledVars: map[string]string{},
 	}
-	cwd, err := os.Getwd()
-	if err != nil {
-		return data, err
-	}
-	path := filepath.Join(cwd, filename)
-	content, err := os.ReadFile(path)
+	content, err := os.ReadFile(httpFilePath(filename))
 	if err != nil {
 		return data, err
 	}
@@ -148,20 +220,41 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 	var req HTTPRequest
 	headerLines := []string{}
 	processingHeaders := false
+	inResponse := false
+	bodyNext := false // a blank line ended the headers
+	blank := false    // the previous line was blank
 
-	for _, line := range lines {
+	for i, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
+		afterBlank := blank
+		blank = trimmedLine == ""
+
+		// The banner is not a request
+		if trimmedLine == fileBanner {
+			continue
+		}
 
 		// Check for section headers
 		if strings.HasPrefix(trimmedLine, "### Global Variables") {
+			// Variables can come after requests too, a ### line with no
+			// request line is only a title
+			if inRequest && (req.Method != "" || req.URL != "") {
+				if processingHeaders {
+					req.Headers = headerLinesToHeaders(headerLines)
+					headerLines = []string{}
+				}
+				data.Requests = append(data.Requests, req)
+			}
+			headerLines = []string{}
 			inGlobals = true
 			inRequest = false
 			processingHeaders = false
+			inResponse = false
 			continue
 		}
 		if strings.HasPrefix(trimmedLine, "### ") && !strings.HasPrefix(trimmedLine, "### Global Variables") {
 			// A new request is starting, so process the previous one if it exists
-			if inRequest {
+			if inRequest && (req.Method != "" || req.URL != "") {
 				// We've finished processing headers for the previous request
 				if processingHeaders {
 					// Manually create a JSON string from header lines. This is fragile.
@@ -187,16 +280,29 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 			req = HTTPRequest{}
 			inGlobals = false
 			inRequest = true
+			inResponse = false
+			bodyNext = false
 			req.Name = strings.TrimPrefix(trimmedLine, "### ")
 			continue
 		}
 
-		// Logic for parsing global variables
-		if inGlobals {
-			if strings.HasPrefix(trimmedLine, "@") {
-				parts := strings.SplitN(trimmedLine[1:], " = ", 2)
-				if len(parts) == 2 {
-					data.GlobalVars[parts[0]] = parts[1]
+		// Declarations after a blank line that end the section of a request
+		// are variables of the file, the body ends before them
+		if inRequest && req.Body != "" && !inResponse && afterBlank && trailingDeclarations(lines[i:]) {
+			inGlobals = true
+		}
+
+		// Logic for parsing global variables, they can also be declared
+		// before the first request
+		if inGlobals || !inRequest {
+			if name, value, ok := parseVariableLine(trimmedLine); ok {
+				data.GlobalVars[name] = value
+				delete(data.DisabledVars, name)
+				data.VarOrder = append(data.VarOrder, name)
+			} else if name, value, ok := parseVariableLine(strings.TrimPrefix(trimmedLine, "# ")); ok && strings.HasPrefix(trimmedLine, "# @") {
+				if _, enabled := data.GlobalVars[name]; !enabled {
+					data.DisabledVars[name] = value
+					data.VarOrder = append(data.VarOrder, name)
 				}
 			}
 			continue
@@ -204,6 +310,17 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 
 		// Logic for parsing requests
 		if inRequest {
+			// Variables of the request go before its body
+			if !inResponse && req.Body == "" {
+				if name, value, ok := parseVariableLine(trimmedLine); ok {
+					if req.Vars == nil {
+						req.Vars = map[string]string{}
+					}
+					req.Vars[name] = value
+					continue
+				}
+			}
+
 			// First line of the request (Method and URL)
 			if req.Method == "" && req.URL == "" && trimmedLine != "" {
 				parts := strings.SplitN(trimmedLine, " ", 2)
@@ -214,8 +331,43 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 				continue
 			}
 
+			// The example response goes on until the next request
+			if inResponse {
+				req.Response += line + "\n"
+				continue
+			}
+			if req.Method != "" && responseStatusLine.MatchString(trimmedLine) {
+				if processingHeaders {
+					req.Headers = headerLinesToHeaders(headerLines)
+					headerLines = []string{}
+					processingHeaders = false
+				}
+				inResponse = true
+				req.Response += line + "\n"
+				continue
+			}
+
+			// Once the body has started every line belongs to it
+			if req.Body != "" {
+				req.Body += line + "\n"
+				continue
+			}
+
+			// Directives go before the body
+			if req.Method != "" && req.Body == "" && strings.HasPrefix(trimmedLine, "# @") {
+				req.Directives = append(req.Directives, strings.TrimPrefix(trimmedLine, "# @"))
+				continue
+			}
+
+			// A blank line after the request line or the headers starts the
+			// body, of any content type
+			if req.Method != "" && !processingHeaders && trimmedLine == "" {
+				bodyNext = true
+				continue
+			}
+
 			// Logic for parsing headers
-			if req.Method != "" && req.URL != "" && !processingHeaders && strings.Contains(line, ":") && !strings.HasPrefix(trimmedLine, "{") && !strings.HasPrefix(trimmedLine, "[") && trimmedLine != "" {
+			if req.Method != "" && req.URL != "" && !processingHeaders && !bodyNext && strings.Contains(line, ":") && !strings.HasPrefix(trimmedLine, "{") && !strings.HasPrefix(trimmedLine, "[") && trimmedLine != "" {
 				processingHeaders = true
 				headerLines = append(headerLines, trimmedLine)
 				continue
@@ -224,6 +376,7 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 			// Continue collecting header lines
 			if processingHeaders {
 				if trimmedLine == "" {
+					bodyNext = true
 					// End of headers, now process them
 					jsonString, err := headerLinesToJSON(headerLines)
 					if err == nil {
@@ -260,8 +413,8 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 				}
 			}
 
-			// Logic for parsing body
-			if strings.HasPrefix(trimmedLine, "{") || strings.HasPrefix(trimmedLine, "[") {
+			// Logic for parsing body, comments between requests are not one
+			if strings.HasPrefix(trimmedLine, "{") || strings.HasPrefix(trimmedLine, "[") || bodyNext && !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, "//") {
 				req.Body += line + "\n"
 				continue
 			}
@@ -287,12 +440,44 @@ func LoadHTTPFile(filename string) (*HTTPFileData, error) {
 
 	// Clean up body by trimming trailing newline
 	for i, r := range data.Requests {
-		data.Requests[i].Body = strings.TrimSpace(r.Body)
+		data.Requests[i].Body = trimBodyTrailer(r.Body)
+		data.Requests[i].Response = strings.TrimSpace(r.Response)
 	}
 
 	return data, nil
 }
 
+// trimBodyTrailer drops the blank and comment lines that end a body, they
+// are between its request and the next one
+func trimBodyTrailer(body string) string {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	for len(lines) > 1 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && !strings.HasPrefix(last, "#") && !strings.HasPrefix(last, "//") {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// headerLinesToHeaders turns "Name: value" lines into the JSON headers of a request
+func headerLinesToHeaders(lines []string) string {
+	jsonString, err := headerLinesToJSON(lines)
+	if err != nil {
+		return ""
+	}
+	headersMap, err := parseHeadersToMap(jsonString)
+	if err != nil {
+		return ""
+	}
+	b, err := json.MarshalIndent(headersMap, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // headerLinesToJSON is a helper function to manually construct a JSON string
 // from the `Header: Value` lines. This is a hacky workaround to use the
 // provided `parseHeadersToMap` function, which expects a single JSON string.
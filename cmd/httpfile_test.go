@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func loadTestHTTPFile(t *testing.T, content string) *HTTPFileData {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "api.http")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func requestNames(data *HTTPFileData) []string {
+	var names []string
+	for _, req := range data.Requests {
+		names = append(names, req.Name)
+	}
+	return names
+}
+
+func TestLoadHTTPFileTitleBeforeGlobals(t *testing.T) {
+	data := loadTestHTTPFile(t, `### POSTBEAR
+### Global Variables
+@host = http://localhost:8080
+
+### users
+GET {{host}}/users
+`)
+	if len(data.Requests) != 1 || data.Requests[0].Name != "users" {
+		t.Fatalf("requests %q, want only users", requestNames(data))
+	}
+	if data.GlobalVars["host"] != "http://localhost:8080" {
+		t.Errorf("host = %q", data.GlobalVars["host"])
+	}
+}
+
+func TestLoadHTTPFileVariablesAfterBody(t *testing.T) {
+	data := loadTestHTTPFile(t, `### create
+POST http://localhost/users
+Content-Type: application/json
+
+{"name": "{{name}}"}
+
+@name = Ann
+@token = abc
+
+### list
+GET http://localhost/users
+`)
+	if len(data.Requests) != 2 {
+		t.Fatalf("requests %q, want create and list", requestNames(data))
+	}
+	if got := data.Requests[0].Body; got != `{"name": "{{name}}"}` {
+		t.Errorf("body %q, the variables are not part of it", got)
+	}
+	if data.GlobalVars["name"] != "Ann" || data.GlobalVars["token"] != "abc" {
+		t.Errorf("file variables %v", data.GlobalVars)
+	}
+
+	// Without a blank line the declaration is part of the body
+	data = loadTestHTTPFile(t, `### text
+POST http://localhost/notes
+
+first line
+@name = Ann
+`)
+	if got := data.Requests[0].Body; got != "first line\n@name = Ann" {
+		t.Errorf("body %q", got)
+	}
+	if _, ok := data.GlobalVars["name"]; ok {
+		t.Error("a line of the body became a file variable")
+	}
+}
+
+func TestLoadHTTPFileDeclarationsInBody(t *testing.T) {
+	data := loadTestHTTPFile(t, `### form
+POST http://localhost/notes
+Content-Type: text/plain
+
+first paragraph
+
+x = y
+
+@a = b
+more text
+
+HTTP/1.1 200 OK
+Content-Type: text/plain
+
+saved
+
+### next
+GET http://localhost/next
+`)
+	if len(data.Requests) != 2 {
+		t.Fatalf("requests %q, want form and next", requestNames(data))
+	}
+	req := data.Requests[0]
+	if want := "first paragraph\n\nx = y\n\n@a = b\nmore text"; req.Body != want {
+		t.Errorf("body %q, want %q", req.Body, want)
+	}
+	if req.Response == "" {
+		t.Error("the example response was dropped")
+	}
+	if len(data.GlobalVars) != 0 {
+		t.Errorf("file variables %v, the lines belong to the body", data.GlobalVars)
+	}
+}
+
+func TestLoadHTTPFileRequestVariables(t *testing.T) {
+	data := loadTestHTTPFile(t, `@host = http://localhost
+
+### one
+@id = 42
+GET {{host}}/users/{{id}}
+`)
+	if len(data.Requests) != 1 {
+		t.Fatalf("requests %q", requestNames(data))
+	}
+	if got := data.Requests[0].Vars["id"]; got != "42" {
+		t.Errorf("request variable id = %q", got)
+	}
+	if _, ok := data.GlobalVars["id"]; ok {
+		t.Error("a request variable became a file variable")
+	}
+}
+
+func TestLoadHTTPFileBodyTrailer(t *testing.T) {
+	data := loadTestHTTPFile(t, `### query
+POST http://localhost/graphql
+Content-Type: application/graphql
+
+query {
+  users { id }
+}
+
+# the next one needs a token
+// and an admin
+
+### text
+POST http://localhost/notes
+
+plain text body
+# kept, the body goes on after it
+end
+# dropped
+
+`)
+	if len(data.Requests) != 2 {
+		t.Fatalf("requests %q", requestNames(data))
+	}
+	if got := data.Requests[0].Body; got != "query {\n  users { id }\n}" {
+		t.Errorf("body %q, the trailing comments are not part of it", got)
+	}
+	if got := data.Requests[1].Body; got != "plain text body\n# kept, the body goes on after it\nend" {
+		t.Errorf("body %q", got)
+	}
+}
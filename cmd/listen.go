@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedRequest is a request received by the inspector
+type CapturedRequest struct {
+	Time    time.Time
+	Method  string
+	Host    string
+	Path    string // with the query string
+	Proto   string
+	Remote  string
+	Headers http.Header
+	Body    string
+}
+
+// Headers that only make sense for the connection the request came on
+var capturedHeaderSkip = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// ToHTTP turns the captured request into a request of a .http file, its
+// URL pointing at the host the sender targeted
+func (c CapturedRequest) ToHTTP() HTTPRequest {
+	headers := map[string]string{}
+	for key, values := range c.Headers {
+		if !capturedHeaderSkip[key] {
+			headers[key] = strings.Join(values, ", ")
+		}
+	}
+	req := HTTPRequest{
+		Name:   fmt.Sprintf("captured %s %s", strings.ToLower(c.Method), c.Time.Format("15:04:05")),
+		Method: c.Method,
+		URL:    "http://" + c.Host + c.Path,
+		Body:   c.Body,
+	}
+	if len(headers) > 0 {
+		b, _ := json.MarshalIndent(headers, "", "  ")
+		req.Headers = string(b)
+	}
+	return req
+}
+
+// Inspector accepts any request, records it and answers with a fixed status
+// and an echo of what it received
+type Inspector struct {
+	Status int
+
+	mu        sync.Mutex
+	onRequest func(CapturedRequest)
+}
+
+// OnRequest sets a function called for each captured request
+func (in *Inspector) OnRequest(fn func(CapturedRequest)) {
+	in.mu.Lock()
+	in.onRequest = fn
+	in.mu.Unlock()
+}
+
+func (in *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	captured := CapturedRequest{
+		Time:    time.Now(),
+		Method:  r.Method,
+		Host:    r.Host,
+		Path:    r.URL.RequestURI(),
+		Proto:   r.Proto,
+		Remote:  r.RemoteAddr,
+		Headers: r.Header.Clone(),
+		Body:    string(body),
+	}
+	in.mu.Lock()
+	onRequest := in.onRequest
+	in.mu.Unlock()
+	if onRequest != nil {
+		onRequest(captured)
+	}
+
+	status := in.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"method":  captured.Method,
+		"path":    captured.Path,
+		"headers": flattenHeader(captured.Headers),
+		"body":    captured.Body,
+	})
+}
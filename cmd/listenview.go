@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type capturedMsg CapturedRequest
+
+// listenView lists the requests captured by the inspector
+type listenView struct {
+	width    int
+	height   int
+	styles   *Styles
+	address  string
+	file     string // .http file captured requests are saved to
+	captured chan CapturedRequest
+	log      []CapturedRequest
+	cursor   int
+	follow   bool
+	message  string
+}
+
+// NewListenView returns the TUI of an inspector listening on address, saving
+// requests to file
+func NewListenView(inspector *Inspector, address, file string) tea.Model {
+	v := listenView{
+		styles:   NewStyles(lipgloss.DefaultRenderer()),
+		address:  address,
+		file:     file,
+		captured: make(chan CapturedRequest, 64),
+		follow:   true,
+	}
+	inspector.OnRequest(func(c CapturedRequest) {
+		v.captured <- c
+	})
+	return v
+}
+
+func waitCaptured(captured chan CapturedRequest) tea.Cmd {
+	return func() tea.Msg {
+		return capturedMsg(<-captured)
+	}
+}
+
+func (v listenView) Init() tea.Cmd {
+	return waitCaptured(v.captured)
+}
+
+func (v listenView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+	case capturedMsg:
+		v.log = append(v.log, CapturedRequest(msg))
+		if v.follow {
+			v.cursor = len(v.log) - 1
+		}
+		return v, waitCaptured(v.captured)
+	case tea.KeyMsg:
+		v.message = ""
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return v, tea.Quit
+		case "up", "k":
+			v.cursor = max(v.cursor-1, 0)
+			v.follow = false
+		case "down", "j":
+			v.cursor = min(v.cursor+1, len(v.log)-1)
+			v.follow = v.cursor == len(v.log)-1
+		case "c":
+			v.log, v.cursor, v.follow = nil, 0, true
+		case "s":
+			if v.cursor >= len(v.log) {
+				return v, nil
+			}
+			req := v.log[v.cursor].ToHTTP()
+			if err := AppendRequest(v.file, req); err != nil {
+				v.message = "Error saving request: " + err.Error()
+			} else {
+				v.message = fmt.Sprintf("Saved %q in %s", req.Name, filepath.Base(v.file))
+			}
+		}
+	}
+	return v, nil
+}
+
+func (v listenView) View() string {
+	header := v.appTopLabel(fmt.Sprintf("POSTBEAR Listen  %s  %d requests", v.address, len(v.log)))
+	innerWidth := max(v.width-6, 10)
+
+	var b strings.Builder
+	if len(v.log) == 0 {
+		b.WriteString(NormalTitleStyle.Render("Waiting for requests, send them to "+v.address) + "\n")
+	}
+	rows := max((v.height-8)/3, 3)
+	start := max(min(v.cursor-rows/2, len(v.log)-rows), 0)
+	for i := start; i < len(v.log) && i < start+rows; i++ {
+		c := v.log[i]
+		line := fmt.Sprintf("%s %-7s %s  %s", c.Time.Format("15:04:05"), c.Method, c.Path, NormalTitleStyle.Render(fmt.Sprintf("%d bytes from %s", len(c.Body), c.Remote)))
+		line = ansi.Truncate(line, innerWidth-2, "…")
+		if i == v.cursor {
+			b.WriteString(v.styles.Highlight.Render("> ") + line + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if v.cursor < len(v.log) {
+		c := v.log[v.cursor]
+		b.WriteString("\n" + requestDetails(c.Method, c.Path+"  "+c.Proto, c.Headers, c.Body))
+	}
+	if v.message != "" {
+		b.WriteString("\n" + v.styles.StatusHeader.Render(v.message))
+	}
+
+	content := lipgloss.NewStyle().Padding(0, 1).MaxHeight(max(v.height-4, 1)).Render(b.String())
+	body := borderStyle.Width(max(v.width-2, 1)).Height(max(v.height-4, 1)).Render(content)
+	footer := v.appBottomLabel(fmt.Sprintf("s to save as a request in %s, up/down to browse, c to clear, q to stop", filepath.Base(v.file)))
+	return v.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
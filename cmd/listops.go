@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	maxUndo        = 50
+	listPanelWidth = 42 // columns taken by the requests list panel, border included
+)
+
+type listSnapshot struct {
+	items []list.Item
+	index int
+}
+
+// pushUndo records the list before a destructive operation
+func (m *Model) pushUndo() {
+	items := make([]list.Item, len(m.requestsList.Items()))
+	copy(items, m.requestsList.Items())
+	m.undoStack = append(m.undoStack, listSnapshot{items: items, index: m.requestsList.Index()})
+	if len(m.undoStack) > maxUndo {
+		m.undoStack = m.undoStack[1:]
+	}
+}
+
+func (m *Model) undo() {
+	if len(m.undoStack) == 0 {
+		m.message = m.appBoundaryMessage("Nothing to undo")
+		return
+	}
+	last := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.requestsList.SetItems(last.items)
+	m.requestsList.Select(last.index)
+	m.loadSelected()
+	m.message = m.appBoundaryMessage("Undone")
+	m.listDirty = true
+}
+
+// loadSelected fills the request fields with the selected request
+func (m *Model) loadSelected() {
+	item, ok := m.requestsList.SelectedItem().(request)
+	if !ok {
+		return
+	}
+	if m.workspace != "" {
+		m.filepath = item.file
+	}
+	m.nameField.SetValue(item.Title())
+	m.methodField.SetValue(strings.ToUpper(item.Method()))
+	m.urlField.SetValue(item.Endpoint())
+	m.bodyArea.SetValue(item.Body())
+	m.headersArea.SetValue(item.Headers())
+	m.paramsTable = NewParamsTable()
+	m.loadParams(item.Endpoint(), item.directives)
+	m.refreshScope()
+}
+
+// loadParams fills the params table with the query of endpoint, and the
+// disabled params and descriptions kept in the directives of the request,
+// and the path table with the path params of endpoint and their values
+func (m *Model) loadParams(endpoint string, directives []string) {
+	m.pathTable = NewPathTable()
+	m.pathTable.width = m.tabContentWidth
+	m.pathTable.SetPathParams(PathParams(endpoint), PathValues(directives))
+	m.pathFocused = false
+	query := ""
+	if idx := strings.Index(endpoint, "?"); idx != -1 {
+		query = endpoint[idx:]
+	}
+	m.paramsTable.width = m.tabContentWidth
+	m.paramsTable.SetFromQueryString(query)
+	m.paramsTable.SetParamDirectives(directives)
+}
+
+// markedIndexes returns the multi-selected requests, or the selected one
+func (m *Model) markedIndexes() []int {
+	var marked []int
+	for i, item := range m.requestsList.Items() {
+		if req, ok := item.(request); ok && req.marked {
+			marked = append(marked, i)
+		}
+	}
+	if len(marked) == 0 {
+		if _, ok := m.requestsList.SelectedItem().(request); ok {
+			marked = []int{m.requestsList.Index()}
+		}
+	}
+	return marked
+}
+
+func (m *Model) toggleMark() {
+	idx := m.requestsList.Index()
+	if req, ok := m.requestsList.SelectedItem().(request); ok {
+		req.marked = !req.marked
+		m.requestsList.SetItem(idx, req)
+	}
+	if idx < len(m.requestsList.Items())-1 {
+		m.requestsList.Select(idx + 1)
+	}
+}
+
+func (m *Model) duplicateSelected() {
+	req, ok := m.requestsList.SelectedItem().(request)
+	if !ok {
+		return
+	}
+	m.pushUndo()
+	req.title += " copy"
+	req.marked = false
+	req.origin = ""
+	idx := m.requestsList.Index() + 1
+	m.requestsList.InsertItem(idx, req)
+	m.requestsList.Select(idx)
+	m.loadSelected()
+	m.listDirty = true
+}
+
+// removeMarked deletes the multi-selected requests, or the selected one,
+// always keeping at least one request in the list
+func (m *Model) removeMarked() {
+	marked := m.markedIndexes()
+	if len(marked) == 0 || len(allRequests(m.requestsList.Items())) <= len(marked) {
+		return
+	}
+	m.pushUndo()
+	for i := len(marked) - 1; i >= 0; i-- {
+		m.requestsList.RemoveItem(marked[i])
+	}
+	m.requestsList.Select(max(marked[0]-1, 0))
+	m.loadSelected()
+	m.listDirty = true
+	m.message = m.appBoundaryMessage("Request removed, press u to undo")
+}
+
+// moveMarked moves the multi-selected requests, or the selected one, one
+// position up (delta -1) or down (delta 1)
+func (m *Model) moveMarked(delta int) {
+	marked := m.markedIndexes()
+	if len(marked) == 0 {
+		return
+	}
+	first, last := marked[0], marked[len(marked)-1]
+	if first+delta < 0 || last+delta >= len(m.requestsList.Items()) {
+		return
+	}
+	m.pushUndo()
+	moved := true
+	if delta < 0 {
+		for _, idx := range marked {
+			moved = moved && m.swapItems(idx, idx-1)
+		}
+	} else {
+		for i := len(marked) - 1; i >= 0; i-- {
+			moved = moved && m.swapItems(marked[i], marked[i]+1)
+		}
+	}
+	if !moved {
+		// Put everything back, one of the requests would leave the files
+		last := m.undoStack[len(m.undoStack)-1]
+		m.undoStack = m.undoStack[:len(m.undoStack)-1]
+		m.requestsList.SetItems(last.items)
+		return
+	}
+	m.requestsList.Select(m.requestsList.Index() + delta)
+	m.listDirty = true
+}
+
+// swapItems swaps a request with its neighbour. In a workspace the request
+// is moved into the file it lands in, and it cannot leave the files.
+func (m *Model) swapItems(from, to int) bool {
+	items := m.requestsList.Items()
+	req, ok := items[from].(request)
+	if !ok {
+		return false
+	}
+	other := items[to]
+	m.requestsList.SetItem(to, req)
+	m.requestsList.SetItem(from, other)
+	if m.workspace == "" {
+		return true
+	}
+	var parent list.Item
+	if to > 0 {
+		parent = m.requestsList.Items()[to-1]
+	}
+	switch p := parent.(type) {
+	case request:
+		req.file, req.depth = p.file, p.depth
+	case treeNode:
+		if p.kind != fileNode || p.collapsed {
+			return false
+		}
+		req.file, req.depth = p.path, p.depth+1
+	default:
+		return false
+	}
+	m.requestsList.SetItem(to, req)
+	return true
+}
+
+func (m *Model) startRename() {
+	req, ok := m.requestsList.SelectedItem().(request)
+	if !ok {
+		return
+	}
+	m.renaming = true
+	m.renameInput = textinput.New()
+	m.renameInput.Prompt = ""
+	m.renameInput.SetValue(req.Title())
+	m.renameInput.CursorEnd()
+	m.renameInput.Focus()
+	m.requestsList.SetDelegate(itemDelegate{rename: m.renameInput.View()})
+}
+
+func (m Model) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "enter":
+		if req, ok := m.requestsList.SelectedItem().(request); ok && strings.TrimSpace(m.renameInput.Value()) != "" {
+			m.pushUndo()
+			req.title = m.renameInput.Value()
+			req.dirty = true
+			m.requestsList.SetItem(m.requestsList.Index(), req)
+			m.nameField.SetValue(req.title)
+		}
+		fallthrough
+	case "esc":
+		m.renaming = false
+		m.requestsList.SetDelegate(itemDelegate{})
+		return m, nil
+	}
+	m.renameInput, cmd = m.renameInput.Update(msg)
+	m.requestsList.SetDelegate(itemDelegate{rename: m.renameInput.View()})
+	return m, cmd
+}
+
+// listIndexAt maps a screen row to an item of the requests list
+func (m *Model) listIndexAt(y int) int {
+	l := m.requestsList
+	header := 1 // panel border
+	header += lipgloss.Height(l.Styles.TitleBar.Render(l.Styles.Title.Render(l.Title)))
+	header += lipgloss.Height(l.Styles.StatusBar.Render(" "))
+	row := y - header
+	if row < 0 {
+		return -1
+	}
+	d := itemDelegate{}
+	idx := l.Paginator.Page*l.Paginator.PerPage + row/(d.Height()+d.Spacing())
+	if idx >= len(l.Items()) {
+		return -1
+	}
+	return idx
+}
+
+// handleListMouse lets requests be picked and dragged with the mouse
+func (m *Model) handleListMouse(msg tea.MouseMsg) {
+	if msg.X >= listPanelWidth {
+		if msg.Action == tea.MouseActionRelease {
+			m.dragging = -1
+		}
+		return
+	}
+	idx := m.listIndexAt(msg.Y)
+	switch msg.Action {
+	case tea.MouseActionPress:
+		if msg.Button != tea.MouseButtonLeft || idx < 0 {
+			return
+		}
+		m.focused = requestsListPanel
+		m.requestsList.Select(idx)
+		m.loadSelected()
+		if _, ok := m.requestsList.SelectedItem().(request); ok {
+			m.dragging = idx
+			m.dragMoved = false
+		}
+	case tea.MouseActionMotion:
+		if m.dragging < 0 || idx < 0 || idx == m.dragging {
+			return
+		}
+		if !m.dragMoved {
+			m.pushUndo()
+			m.dragMoved = true
+		}
+		step := 1
+		if idx < m.dragging {
+			step = -1
+		}
+		for m.dragging != idx {
+			if !m.swapItems(m.dragging, m.dragging+step) {
+				// Put it back where it was, the target is outside the files
+				m.swapItems(m.dragging+step, m.dragging)
+				break
+			}
+			m.dragging += step
+		}
+		m.listDirty = true
+		m.requestsList.Select(m.dragging)
+	case tea.MouseActionRelease:
+		m.dragging = -1
+	}
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockResponse is the example response of a request:
+//
+//	HTTP/1.1 200 OK
+//	# @delay 300ms
+//	Content-Type: application/json
+//
+//	{"id": "{{id}}"}
+type MockResponse struct {
+	Status  int
+	Headers http.Header
+	Body    string
+	Delay   time.Duration
+}
+
+// ParseMockResponse reads the example response of a request
+func ParseMockResponse(raw string) (MockResponse, error) {
+	resp := MockResponse{Status: http.StatusOK, Headers: http.Header{}}
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || !responseStatusLine.MatchString(strings.TrimSpace(lines[0])) {
+		return resp, fmt.Errorf("an example response starts with a status line like 'HTTP/1.1 200 OK'")
+	}
+	fields := strings.Fields(lines[0])
+	status, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return resp, fmt.Errorf("invalid status %q", fields[1])
+	}
+	resp.Status = status
+
+	i := 1
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" {
+			i++
+			break
+		}
+		if directive, ok := strings.CutPrefix(line, "# @delay"); ok {
+			delay, err := time.ParseDuration(strings.TrimSpace(directive))
+			if err != nil {
+				return resp, fmt.Errorf("invalid delay %q, e.g. 300ms", strings.TrimSpace(directive))
+			}
+			resp.Delay = delay
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// A body right after the status line, without headers
+		if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+			break
+		}
+		key, value, err := ParseHeaderLine(line)
+		if err != nil {
+			return resp, err
+		}
+		resp.Headers.Add(key, value)
+	}
+	if i < len(lines) {
+		resp.Body = strings.TrimSpace(strings.Join(lines[i:], "\n"))
+	}
+	if resp.Body != "" && resp.Headers.Get("Content-Type") == "" && json.Valid([]byte(resp.Body)) {
+		resp.Headers.Set("Content-Type", "application/json")
+	}
+	return resp, nil
+}
+
+type mockRoute struct {
+	name     string
+	method   string
+	pattern  string
+	segments []string
+	response *MockResponse // nil answers 204 No Content
+}
+
+// MockLogEntry is a request the mock server answered
+type MockLogEntry struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Route    string // name of the matched request, empty when nothing matched
+	Status   int
+	Headers  http.Header
+	Body     string
+	Duration time.Duration
+}
+
+// MockServer answers the requests of a .http file with their example responses
+type MockServer struct {
+	routes []mockRoute
+	vars   map[string]string
+
+	mu        sync.Mutex
+	onRequest func(MockLogEntry)
+}
+
+// NewMockServer builds the routes of a .http file, their URLs resolved with
+// the file globals and the selected environment
+func NewMockServer(file, envName string) (*MockServer, error) {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := ResolveVariables(file, envName)
+	if err != nil {
+		return nil, err
+	}
+	s := &MockServer{vars: vars}
+	for _, req := range data.Requests {
+		pattern := mockPath(replacePlaceholders(req.URL, vars))
+		route := mockRoute{
+			name:     req.Name,
+			method:   strings.ToUpper(req.Method),
+			pattern:  pattern,
+			segments: splitPath(pattern),
+		}
+		if req.Response != "" {
+			resp, err := ParseMockResponse(req.Response)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", req.Name, err)
+			}
+			route.response = &resp
+		}
+		s.routes = append(s.routes, route)
+	}
+	if len(s.routes) == 0 {
+		return nil, fmt.Errorf("%s has no requests to serve", file)
+	}
+	return s, nil
+}
+
+// OnRequest sets a function called after each answered request
+func (s *MockServer) OnRequest(fn func(MockLogEntry)) {
+	s.mu.Lock()
+	s.onRequest = fn
+	s.mu.Unlock()
+}
+
+// Routes lists the served routes as "METHOD /path  name"
+func (s *MockServer) Routes() []string {
+	var routes []string
+	for _, r := range s.routes {
+		routes = append(routes, fmt.Sprintf("%-7s %s  %s", r.method, r.pattern, r.name))
+	}
+	return routes
+}
+
+// mockPath keeps the path of a request URL, which may lack a scheme and host
+func mockPath(rawURL string) string {
+	if i := strings.Index(rawURL, "?"); i != -1 {
+		rawURL = rawURL[:i]
+	}
+	if strings.Contains(rawURL, "://") {
+		if u, err := url.Parse(rawURL); err == nil {
+			rawURL = u.Path
+		} else if i := strings.Index(rawURL[strings.Index(rawURL, "://")+3:], "/"); i != -1 {
+			rawURL = rawURL[strings.Index(rawURL, "://")+3+i:]
+		}
+	} else if !strings.HasPrefix(rawURL, "/") {
+		// host/path without a scheme
+		if i := strings.Index(rawURL, "/"); i != -1 {
+			rawURL = rawURL[i:]
+		} else {
+			rawURL = "/"
+		}
+	}
+	if rawURL == "" {
+		rawURL = "/"
+	}
+	return rawURL
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// pathParam returns the name of a {id}, :id or {{id}} segment
+func pathParam(segment string) (string, bool) {
+	switch {
+	case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+		return segment[2 : len(segment)-2], true
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+		return segment[1 : len(segment)-1], true
+	case strings.HasPrefix(segment, ":") && len(segment) > 1:
+		return segment[1:], true
+	}
+	return "", false
+}
+
+// match reports whether path fits the route and returns its path parameters
+func (r mockRoute) match(path string) (map[string]string, bool) {
+	segments := splitPath(path)
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, segment := range r.segments {
+		if name, ok := pathParam(segment); ok {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				value = segments[i]
+			}
+			params[name] = value
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	body, _ := io.ReadAll(r.Body)
+	entry := MockLogEntry{
+		Time:    startTime,
+		Method:  r.Method,
+		Path:    r.URL.RequestURI(),
+		Headers: r.Header.Clone(),
+		Body:    string(body),
+	}
+	entry.Status, entry.Route = s.answer(w, r)
+	entry.Duration = time.Since(startTime)
+
+	s.mu.Lock()
+	onRequest := s.onRequest
+	s.mu.Unlock()
+	if onRequest != nil {
+		onRequest(entry)
+	}
+}
+
+// answer writes the response of the first matching route
+func (s *MockServer) answer(w http.ResponseWriter, r *http.Request) (int, string) {
+	// Front-ends call the mock from another origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		w.Header().Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+		w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
+		w.WriteHeader(http.StatusNoContent)
+		return http.StatusNoContent, ""
+	}
+
+	var allowed []string
+	for _, route := range s.routes {
+		params, ok := route.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+		if route.response == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return http.StatusNoContent, route.name
+		}
+		resp := route.response
+		if resp.Delay > 0 {
+			select {
+			case <-time.After(resp.Delay):
+			case <-r.Context().Done():
+				return 0, route.name
+			}
+		}
+		vars := map[string]string{}
+		for k, v := range s.vars {
+			vars[k] = v
+		}
+		for k, v := range params {
+			vars[k] = v
+		}
+		for key, values := range resp.Headers {
+			for _, v := range values {
+				w.Header().Add(key, replacePlaceholders(v, vars))
+			}
+		}
+		w.WriteHeader(resp.Status)
+		io.WriteString(w, replacePlaceholders(resp.Body, vars))
+		return resp.Status, route.name
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(allowed) > 0 {
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "method not allowed", "allowed": allowed})
+		return http.StatusMethodNotAllowed, ""
+	}
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": "no request of the file matches", "routes": s.Routes()})
+	return http.StatusNotFound, ""
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mockFile = `@host = http://localhost:8080
+
+### get user
+GET {{host}}/users/{id}
+
+HTTP/1.1 200 OK
+Content-Type: application/json
+
+{"id": "{{id}}", "name": "Ann"}
+
+### user orders
+GET {{host}}/users/:id/orders
+
+HTTP/1.1 200 OK
+X-User: {{id}}
+
+[]
+
+### slow
+GET {{host}}/slow
+
+HTTP/1.1 202 Accepted
+# @delay 200ms
+
+done
+
+### create user
+POST {{host}}/users
+
+### delete user
+DELETE {{host}}/users/{id}
+
+HTTP/1.1 404 Not Found
+`
+
+func newTestMockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "api.http")
+	if err := os.WriteFile(file, []byte(mockFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMockServer(file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func mockCall(t *testing.T, srv *httptest.Server, method, path string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestMockServerRoutes(t *testing.T) {
+	srv := newTestMockServer(t)
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/users/42", 200, `{"id": "42", "name": "Ann"}`},
+		{"GET", "/users/a%20b", 200, `{"id": "a b", "name": "Ann"}`},
+		{"GET", "/users/42/", 200, `{"id": "42", "name": "Ann"}`},
+		{"GET", "/users/42/orders", 200, `[]`},
+		{"POST", "/users", 204, ""},
+		{"DELETE", "/users/42", 404, ""},
+	}
+	for _, tt := range tests {
+		resp, body := mockCall(t, srv, tt.method, tt.path)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, resp.StatusCode, tt.status)
+		}
+		if body != tt.body {
+			t.Errorf("%s %s: body %q, want %q", tt.method, tt.path, body, tt.body)
+		}
+	}
+
+	resp, _ := mockCall(t, srv, "GET", "/users/7")
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	resp, _ = mockCall(t, srv, "GET", "/users/7/orders")
+	if got := resp.Header.Get("X-User"); got != "7" {
+		t.Errorf("X-User = %q, want the path param", got)
+	}
+
+	// A known path with another method lists the allowed ones
+	resp, _ = mockCall(t, srv, "PUT", "/users/42")
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("PUT status %d, want 405", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Allow"); got != "DELETE, GET" {
+		t.Errorf("Allow = %q", got)
+	}
+
+	resp, body := mockCall(t, srv, "GET", "/nothing/here")
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(body, "no request of the file matches") {
+		t.Errorf("unknown path: status %d, body %q", resp.StatusCode, body)
+	}
+}
+
+func TestMockServerDelay(t *testing.T) {
+	srv := newTestMockServer(t)
+
+	start := time.Now()
+	resp, body := mockCall(t, srv, "GET", "/slow")
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("answered after %v, want the 200ms delay", elapsed)
+	}
+	if resp.StatusCode != http.StatusAccepted || body != "done" {
+		t.Errorf("status %d, body %q", resp.StatusCode, body)
+	}
+
+	start = time.Now()
+	mockCall(t, srv, "GET", "/users/1")
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("a route without delay took %v", elapsed)
+	}
+}
+
+func TestParseMockResponse(t *testing.T) {
+	resp, err := ParseMockResponse("HTTP/1.1 201 Created\n# @delay 1s\n{\"ok\": true}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != 201 || resp.Delay != time.Second || resp.Body != `{"ok": true}` {
+		t.Errorf("got %+v", resp)
+	}
+	if got := resp.Headers.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want it guessed from the body", got)
+	}
+
+	for _, raw := range []string{"200 OK", "HTTP/1.1 200 OK\n# @delay soon", "HTTP/1.1 200 OK\nnot a header"} {
+		if _, err := ParseMockResponse(raw); err == nil {
+			t.Errorf("ParseMockResponse(%q) accepted it", raw)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type mockLogMsg MockLogEntry
+
+// mockView shows the routes of a mock server and the requests it answers
+type mockView struct {
+	width   int
+	height  int
+	styles  *Styles
+	address string
+	routes  []string
+	entries chan MockLogEntry
+	log     []MockLogEntry
+	cursor  int
+	follow  bool // keep the newest request selected
+}
+
+// NewMockView returns the TUI of a mock server listening on address
+func NewMockView(server *MockServer, address string) tea.Model {
+	v := mockView{
+		styles:  NewStyles(lipgloss.DefaultRenderer()),
+		address: address,
+		routes:  server.Routes(),
+		entries: make(chan MockLogEntry, 64),
+		follow:  true,
+	}
+	server.OnRequest(func(e MockLogEntry) {
+		v.entries <- e
+	})
+	return v
+}
+
+func waitMockLog(entries chan MockLogEntry) tea.Cmd {
+	return func() tea.Msg {
+		return mockLogMsg(<-entries)
+	}
+}
+
+func (v mockView) Init() tea.Cmd {
+	return waitMockLog(v.entries)
+}
+
+func (v mockView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+	case mockLogMsg:
+		v.log = append(v.log, MockLogEntry(msg))
+		if v.follow {
+			v.cursor = len(v.log) - 1
+		}
+		return v, waitMockLog(v.entries)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return v, tea.Quit
+		case "up", "k":
+			v.cursor = max(v.cursor-1, 0)
+			v.follow = false
+		case "down", "j":
+			v.cursor = min(v.cursor+1, len(v.log)-1)
+			v.follow = v.cursor == len(v.log)-1
+		case "c":
+			v.log, v.cursor, v.follow = nil, 0, true
+		}
+	}
+	return v, nil
+}
+
+// logLine renders a request of the log on one line
+func logLine(e MockLogEntry) string {
+	status := "  -  "
+	if e.Status != 0 {
+		status = statusCodeStyle(fmt.Sprint(e.Status)).Render(fmt.Sprintf(" %d ", e.Status))
+	}
+	route := e.Route
+	if route == "" {
+		route = NormalTitleStyle.Render("no match")
+	}
+	return fmt.Sprintf("%s %s %-7s %s  %s %s", e.Time.Format("15:04:05"), status, e.Method, e.Path, route, responseTimeStyle.Render(fmt.Sprintf(" %vms ", e.Duration.Milliseconds())))
+}
+
+// requestDetails renders the headers and the body of a received request
+func requestDetails(method, path string, headers map[string][]string, body string) string {
+	labelStyle := boldStyle.Foreground(lipgloss.Color("6"))
+	var b strings.Builder
+	b.WriteString(boldStyle.Render(method+" "+path) + "\n")
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(labelStyle.Render(k+":") + " " + strings.Join(headers[k], ", ") + "\n")
+	}
+	if body != "" {
+		b.WriteString("\n" + formatJSON(body) + "\n")
+	}
+	return b.String()
+}
+
+func (v mockView) View() string {
+	header := v.appTopLabel(fmt.Sprintf("POSTBEAR Mock  %s  %d requests", v.address, len(v.log)))
+	innerWidth := max(v.width-6, 10)
+
+	var b strings.Builder
+	b.WriteString(v.styles.StatusHeader.Render("Routes") + "\n")
+	for i, route := range v.routes {
+		if i == 5 && len(v.routes) > 6 {
+			b.WriteString(NormalTitleStyle.Render(fmt.Sprintf("  ... %d more", len(v.routes)-5)) + "\n")
+			break
+		}
+		b.WriteString("  " + ansi.Truncate(route, innerWidth-2, "…") + "\n")
+	}
+	b.WriteString("\n" + v.styles.StatusHeader.Render("Requests") + "\n")
+	if len(v.log) == 0 {
+		b.WriteString(NormalTitleStyle.Render("  Waiting for requests...") + "\n")
+	}
+	rows := max((v.height-16)/2, 3)
+	start := max(min(v.cursor-rows/2, len(v.log)-rows), 0)
+	for i := start; i < len(v.log) && i < start+rows; i++ {
+		line := ansi.Truncate(logLine(v.log[i]), innerWidth-2, "…")
+		if i == v.cursor {
+			b.WriteString(v.styles.Highlight.Render("> ") + line + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if v.cursor < len(v.log) {
+		e := v.log[v.cursor]
+		b.WriteString("\n" + requestDetails(e.Method, e.Path, e.Headers, e.Body))
+	}
+
+	content := lipgloss.NewStyle().Padding(0, 1).MaxHeight(max(v.height-4, 1)).Render(b.String())
+	body := borderStyle.Width(max(v.width-2, 1)).Height(max(v.height-4, 1)).Render(content)
+	footer := v.appBottomLabel("up/down to browse, c to clear, q to stop the server")
+	return v.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
@@ -2,7 +2,8 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
@@ -12,7 +13,6 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/muesli/reflow/wordwrap"
 )
 
 type Model struct {
@@ -26,6 +26,8 @@ type Model struct {
 	methodField      textinput.Model
 	tabs             []string
 	paramsTable      ParamsTable    // Params tab
+	pathTable        ParamsTable    // Path Variables of the Params tab
+	pathFocused      bool           // the Params tab edits the path variables
 	bodyArea         textarea.Model // Body tab
 	headersArea      textarea.Model // Headers tab
 	responseViewport viewport.Model
@@ -41,6 +43,29 @@ type Model struct {
 	loading          bool
 	tabContentWidth  int
 	filepath         string
+	workspace        string // directory opened as a workspace, empty for a single file
+	undoStack        []listSnapshot
+	renaming         bool
+	renameInput      textinput.Model
+	dragging         int // index of the request dragged with the mouse, -1 when none
+	dragMoved        bool
+	listDirty        bool // requests were added, removed or moved since the last save
+	quitPending      bool
+	sendPending      bool // the request uses undefined variables, enter again sends it anyway
+	autosave         bool
+	stamps           map[string]fileStamp
+	conflicts        []string // files changed on disk by someone else
+	overwritePending bool
+	watchID          int
+	environment      string                    // environment of http-client.env.json used to resolve variables
+	lastResponses    map[string]ResponseRecord // latest response of each request, for the diff
+	prevResponses    map[string]ResponseRecord // the one before it
+	contract         string                    // contract report of the response, empty when the file has no OpenAPI spec
+	responseTab      int                       // responseBodyTab or responseContractTab
+	fileScope        map[string]Variable       // variables of the file and environment, see refreshScope
+	scopeErr         error
+	scope            map[string]Variable // fileScope with the variables of the request in the fields
+	references       []Variable          // {{variables}} of the request in the fields
 }
 
 const (
@@ -56,12 +81,16 @@ const (
 	paramsTab = iota
 	bodyTab
 	headersTab
+	variablesTab
 )
 
 type responseMsg struct {
 	response     string
 	statusCode   string
 	responseTime string
+	key          string // responseKey of the request
+	record       ResponseRecord
+	contract     string
 }
 
 func NewModel(filepath string) Model {
@@ -69,7 +98,7 @@ func NewModel(filepath string) Model {
 	m.lg = lipgloss.DefaultRenderer()
 	m.styles = NewStyles(m.lg)
 	m.id = ""
-	m.tabs = []string{"Params", "Body", "Headers"}
+	m.tabs = []string{"Params", "Body", "Headers", "Variables"}
 
 	m.filepath = filepath
 	m.nameField = textinput.New()
@@ -100,19 +129,22 @@ func NewModel(filepath string) Model {
 	"Accept-Encoding":"gzip, deflate, br",
 	"Connection":"keep-alive"
 }`
-	// Load requests from the current .http file (if any)
+	// Load requests from the current .http file (if any), or every file of a workspace
 	var items []list.Item
-	if data, err := LoadHTTPFile(m.filepath); err == nil {
+	if IsWorkspace(filepath) {
+		m.workspace = filepath
+		m.filepath = ""
+		if files, err := DiscoverHTTPFiles(filepath); err == nil && len(files) > 0 {
+			items = workspaceItems(filepath, files)
+			m.filepath = files[0]
+		} else {
+			// Nothing to show as a tree, start a new file in the directory
+			m.workspace = ""
+			m.filepath = path.Join(filepath, "postbear.http")
+		}
+	} else if data, err := LoadHTTPFile(m.filepath); err == nil {
 		for _, req := range data.Requests {
-			items = append(items, request{
-				title:    req.Name,
-				desc:     req.Method,
-				method:   req.Method,
-				endpoint: req.URL,
-				body:     req.Body,
-				params:   req.Params,
-				headers:  req.Headers,
-			})
+			items = append(items, requestFromHTTP(req))
 		}
 	}
 	if len(items) == 0 {
@@ -121,6 +153,13 @@ func NewModel(filepath string) Model {
 		}
 	}
 	m.requestsList = list.New(items, itemDelegate{}, 0, 0)
+	// Start on the first request rather than on a folder
+	for i, item := range items {
+		if _, ok := item.(request); ok {
+			m.requestsList.Select(i)
+			break
+		}
+	}
 
 	m.requestsList.Title = "POSTBEAR"
 	m.requestsList.SetStatusBarItemName("request", "requests")
@@ -138,6 +177,7 @@ func NewModel(filepath string) Model {
 
 	m.activeTab = paramsTab
 	m.paramsTable = NewParamsTable()
+	m.pathTable = NewPathTable()
 	m.bodyArea = newTextarea()
 	m.bodyArea.Placeholder = `
 { "your":"body" }`
@@ -148,6 +188,9 @@ func NewModel(filepath string) Model {
 	m.responseViewport = vp
 
 	m.focused = requestsListPanel
+	m.dragging = -1
+	m.lastResponses = map[string]ResponseRecord{}
+	m.prevResponses = map[string]ResponseRecord{}
 	m.fields = []string{"requestList", "nameField", "methodField", "urlField", "tabContent", "responseViewport"}
 
 	m.spinner = spinner.New()
@@ -155,12 +198,29 @@ func NewModel(filepath string) Model {
 	m.spinner.Style = spinnerStyle
 	m.loading = false
 
+	m.autosave = AutosaveFromEnv()
+	m.stampFiles()
+	m.refreshScope()
+
+	return m
+}
+
+// WithEnvironment selects the environment used to resolve {{variables}}
+func (m Model) WithEnvironment(name string) Model {
+	m.environment = name
+	m.refreshScope()
+	return m
+}
+
+// WithAutosave turns autosave on or off
+func (m Model) WithAutosave(enabled bool) Model {
+	m.autosave = enabled
 	return m
 }
 
 // Init is run once when the program starts
 func (m Model) Init() tea.Cmd {
-	return m.spinner.Tick
+	return tea.Batch(m.spinner.Tick, watchTick(m.watchID))
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -176,38 +236,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.tabContentWidth = (m.width - 40 - 8) / 2
 		m.bodyArea.MaxWidth = m.tabContentWidth
 		m.paramsTable.width = m.tabContentWidth
+		m.pathTable.width = m.tabContentWidth
 		m.headersArea.MaxWidth = m.tabContentWidth
 		m.message = m.appBoundaryView("Ctrl+c to quit, Ctrl+h for help")
+	case tea.MouseMsg:
+		m.handleListMouse(msg)
 	case tea.KeyMsg:
+		if m.renaming {
+			return m.updateRename(msg)
+		}
+		if msg.String() != "ctrl+c" {
+			m.quitPending = false
+		}
+		if msg.String() != "ctrl+s" {
+			m.overwritePending = false
+		}
+		sendPending := m.sendPending
+		m.sendPending = false
 		switch keypress := msg.String(); keypress {
 		case "ctrl+c":
-			return m, tea.Quit
+			return m.confirmQuit()
 		case "enter":
+			if m.focused == requestsListPanel {
+				if _, ok := m.requestsList.SelectedItem().(treeNode); ok {
+					toggleNode(&m.requestsList, m.requestsList.Index())
+					return m, nil
+				}
+			}
 			if m.focused != 4 {
+				undefined := m.undefinedVariables()
+				// The secrets of the file store wait for its passphrase
+				if secretsLocked() && slices.ContainsFunc(undefined, func(name string) bool { return strings.HasPrefix(name, secretPrefix) }) {
+					return newPassphraseScreen(m), nil
+				}
+				if len(undefined) > 0 && !sendPending {
+					m.sendPending = true
+					m.message = m.appBoundaryMessage("Undefined variables: " + strings.Join(undefined, ", ") + ", enter again to send anyway")
+					return m, nil
+				}
 				m.loading = true
 				m.message = m.appBoundaryMessage("Sending Request....")
 				m.spinner, cmd = m.spinner.Update(msg)
 				cmds = append(cmds, cmd)
 				// Perform the async operation in a goroutine
 				return m, func() tea.Msg {
-					response, statusCode, responseTime := sendByTUI(m) // Simulate the send function
-					formattedResponse := formatJSON(response)
-					responseTime = responseTimeStyle.Render(responseTime)
-					statusStyle := codes200Style
-					statusCodeInt, _ := strconv.Atoi(statusCode)
-					if statusCodeInt >= 500 {
-						statusStyle = codes500Style
-					} else if statusCodeInt >= 400 && statusCodeInt < 500 {
-						statusStyle = codes400Style
-					} else if statusCodeInt >= 300 && statusCodeInt < 400 {
-						statusStyle = codes300Style
+					response, statusCode, responseTime, headers := sendByTUI(m, m.environment) // Simulate the send function
+					// Checked and formatted as received, the secrets are masked in the rendered text
+					record := ResponseRecord{
+						Label:   responseLabel(m.environment),
+						Status:  statusCode,
+						Headers: flattenHeader(headers),
+						Body:    response,
 					}
-
-					statusCode = statusStyle.Render(statusCode)
+					contract := MaskSecrets(contractView(m, statusCode, headers, response))
+					formattedResponse := MaskSecrets(formatJSON(response))
+					responseTime = responseTimeStyle.Render(responseTime)
+					statusCode = statusCodeStyle(statusCode).Render(statusCode)
 					return responseMsg{
 						response:     formattedResponse,
 						statusCode:   statusCode,
 						responseTime: responseTime,
+						key:          responseKey(m),
+						record:       record,
+						contract:     contract,
 					}
 				}
 			}
@@ -216,15 +307,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			help := newHelp(m.width, m.height, m.styles, &m)
 			return help, nil
 		case "shift+right":
+			if m.focused == responseViewportPanel && m.contract != "" {
+				m.responseTab = responseContractTab
+				m.showResponseTab()
+				return m, nil
+			}
 			m.activeTab = min(m.activeTab+1, len(m.tabs)-1)
 			return m, nil
 		case "shift+left":
+			if m.focused == responseViewportPanel && m.contract != "" {
+				m.responseTab = responseBodyTab
+				m.showResponseTab()
+				return m, nil
+			}
 			m.activeTab = max(m.activeTab-1, 0)
 			return m, nil
 		case "ctrl+e":
 			environment := environment(m)
 			return environment, nil
+		case "ctrl+p":
+			return quickOpen(m), nil
+		case "ctrl+o":
+			return commandPalette(m), nil
+		case "alt+n":
+			return environmentPalette(m), nil
+		case "alt+x":
+			m.exportCurl()
+			return m, nil
+		case "ctrl+b":
+			return newBenchScreen(m)
+		case "alt+d":
+			return newDiffScreen(m), nil
+		case "ctrl+r":
+			return newRunnerScreen(m), nil
 		case "ctrl+s":
+			// Don't clobber a file someone else changed unless asked twice,
+			// even one the watch has not noticed yet
+			if !m.overwritePending {
+				m.checkExternalChanges()
+			}
+			if len(m.conflicts) > 0 && !m.overwritePending {
+				m.overwritePending = true
+				m.message = m.appBoundaryMessage(fmt.Sprintf("%s changed on disk! Ctrl+s again to overwrite it, Alt+r to reload, Alt+m to merge", m.conflicts[0]))
+				return m, nil
+			}
+			overwrite := m.overwritePending
+			m.overwritePending = false
+			m.conflicts = nil
 
 			m.loading = true
 			m.message = m.appBoundaryMessage("Saving Request....")
@@ -232,13 +361,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// cmds = append(cmds, cmd)
 
 			// Perform the async save operation in a goroutine
-			return m, func() tea.Msg {
-				saveFile(m)
-				return saveMsg{
-					success: true,
-					message: fmt.Sprintf("Request Saved in %s Successfully!", m.filepath),
-				}
+			return m, saveCmd(m, overwrite)
+		case "alt+r", "alt+m":
+			m.resolveConflict(keypress == "alt+m")
+			return m, nil
+		case "alt+s":
+			m.autosave = !m.autosave
+			if m.autosave {
+				m.message = m.appBoundaryMessage("Autosave enabled")
+			} else {
+				m.message = m.appBoundaryMessage("Autosave disabled")
 			}
+			return m, nil
+		case "alt+u":
+			return newPassphraseScreen(m), nil
 
 		case "tab":
 			m.focused = (m.focused + 1) % len(m.fields)
@@ -268,8 +404,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					params:   "",
 					headers:  headers,
 				}
-				m.requestsList.InsertItem(len(m.requestsList.Items()), newReq)
-				m.requestsList.Select(len(m.requestsList.Items()) - 1)
+				insertAt := len(m.requestsList.Items())
+				if m.workspace != "" {
+					// New requests go right after the selection, in the same file
+					switch item := m.requestsList.SelectedItem().(type) {
+					case request:
+						newReq.file = item.file
+						newReq.depth = item.depth
+					case treeNode:
+						if item.kind != fileNode {
+							m.message = m.appBoundaryMessage("Select a file or a request to add a new request")
+							return m, nil
+						}
+						if item.collapsed {
+							toggleNode(&m.requestsList, m.requestsList.Index())
+						}
+						newReq.file = item.path
+						newReq.depth = item.depth + 1
+					}
+					insertAt = m.requestsList.Index() + 1
+				}
+				m.requestsList.InsertItem(insertAt, newReq)
+				m.requestsList.Select(insertAt)
+				m.listDirty = true
 				// Update fields to match new request
 				m.nameField.SetValue(newReq.title)
 				m.methodField.SetValue(strings.ToUpper(newReq.method))
@@ -278,31 +435,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.headersArea.SetValue(newReq.headers)
 				m.paramsTable = NewParamsTable()
 				m.paramsTable.width = m.tabContentWidth
+				m.pathTable = NewPathTable()
+				m.pathFocused = false
 				return m, nil
 			}
 		case "r":
-			// Remove the current request if more than one exists
+			// Remove the marked requests, or the current one, if more than one exists
 			if m.focused == requestsListPanel {
-				if len(m.requestsList.Items()) > 1 {
-					idx := m.requestsList.Index()
-					m.requestsList.RemoveItem(idx)
-					// Select the previous item if possible, otherwise the first
-					if idx > 0 {
-						m.requestsList.Select(idx - 1)
-					} else {
-						m.requestsList.Select(0)
-					}
-					// Update fields to match the selected request
-					if item, ok := m.requestsList.SelectedItem().(request); ok {
-						m.nameField.SetValue(item.Title())
-						m.methodField.SetValue(strings.ToUpper(item.Method()))
-						m.urlField.SetValue(item.Endpoint())
-						m.bodyArea.SetValue(item.Body())
-						m.headersArea.SetValue(item.Headers())
-						m.paramsTable = NewParamsTable()
-						m.paramsTable.width = m.tabContentWidth
-					}
+				m.removeMarked()
+				return m, nil
+			}
+		case "d":
+			if m.focused == requestsListPanel {
+				m.duplicateSelected()
+				return m, nil
+			}
+		case "e", "f2":
+			if m.focused == requestsListPanel {
+				m.startRename()
+				return m, nil
+			}
+		case " ":
+			if m.focused == requestsListPanel {
+				m.toggleMark()
+				return m, nil
+			}
+		case "shift+up", "shift+down":
+			if m.focused == requestsListPanel {
+				if keypress == "shift+up" {
+					m.moveMarked(-1)
+				} else {
+					m.moveMarked(1)
 				}
+				return m, nil
+			}
+		case "u":
+			if m.focused == requestsListPanel {
+				m.undo()
+				return m, nil
 			}
 		}
 	}
@@ -315,15 +485,50 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.response = msg.response
 		m.responseTime = msg.responseTime
 		m.statusCode = msg.statusCode
+		// Requests that failed to send have no status to compare
+		if msg.record.Status != "" {
+			if last, ok := m.lastResponses[msg.key]; ok {
+				m.prevResponses[msg.key] = last
+			}
+			m.lastResponses[msg.key] = msg.record
+		}
+		m.contract = msg.contract
+		if m.contract == "" {
+			m.responseTab = responseBodyTab
+		}
 		m.loading = false
 		m.message = m.appBoundaryMessage("Request Sent!")
-
-		wrappedContent := wordwrap.String(m.response, m.responseViewport.Width)
-		m.responseViewport.SetContent(wrappedContent)
-		m.responseViewport.GotoTop()
+		m.showResponseTab()
 	case saveMsg:
 		m.loading = false
 		m.message = m.appBoundaryMessage(msg.message)
+		for _, file := range msg.conflicts {
+			if !containsString(m.conflicts, file) {
+				m.conflicts = append(m.conflicts, file)
+			}
+			m.overwritePending = true
+		}
+		if msg.success {
+			for file, stamp := range msg.stamps {
+				m.stamps[file] = stamp
+			}
+			m.requestsList.SetItems(clearDirty(m.requestsList.Items()))
+			m.listDirty = false
+			m.refreshScope()
+		}
+	case watchMsg:
+		// Ticks of a previous watch loop are dropped, only one loop runs
+		if msg.id != m.watchID {
+			return m, nil
+		}
+		m.checkExternalChanges()
+		m.refreshScope()
+		cmds := []tea.Cmd{watchTick(m.watchID)}
+		if m.autosave && !m.loading && len(m.conflicts) == 0 && m.isDirty() {
+			m.loading = true
+			cmds = append(cmds, saveCmd(m, false))
+		}
+		return m, tea.Batch(cmds...)
 
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -337,25 +542,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.requestsList, cmdTemp = m.requestsList.Update(msg)
 		cmds = append(cmds, cmdTemp)
 		// If the index changed, update all fields to match the selected request
+		if node, ok := m.requestsList.SelectedItem().(treeNode); ok && node.kind == fileNode {
+			m.filepath = node.path
+		}
 		if item, ok := m.requestsList.SelectedItem().(request); ok {
+			if m.workspace != "" {
+				m.filepath = item.file
+			}
 			m.nameField.SetValue(item.Title())
 			m.methodField.SetValue(strings.ToUpper(item.Method()))
 			m.urlField.SetValue(item.Endpoint())
 			m.bodyArea.SetValue(item.Body())
 			m.headersArea.SetValue(item.Headers())
 			// Sync paramsTable to selected request
-			m.paramsTable.SetFromQueryString("") // Clear first
-			if idx := strings.Index(item.Endpoint(), "?"); idx != -1 {
-				m.paramsTable.SetFromQueryString(item.Endpoint()[idx:])
-			}
+			m.loadParams(item.Endpoint(), item.directives)
 		}
 	case nameFieldPanel:
 		m.nameField, cmd = m.nameField.Update(msg)
 		cmds = append(cmds, cmd)
 		// Sync change to requestsList
 		if idx := m.requestsList.Index(); idx >= 0 {
-			if item, ok := m.requestsList.SelectedItem().(request); ok {
+			if item, ok := m.requestsList.SelectedItem().(request); ok && item.title != m.nameField.Value() {
 				item.title = m.nameField.Value()
+				item.dirty = true
 				m.requestsList.SetItem(idx, item)
 			}
 		}
@@ -365,9 +574,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		// Sync change to requestsList
 		if idx := m.requestsList.Index(); idx >= 0 {
-			if item, ok := m.requestsList.SelectedItem().(request); ok {
+			if item, ok := m.requestsList.SelectedItem().(request); ok && item.method != m.methodField.Value() {
 				item.method = m.methodField.Value()
 				item.desc = m.methodField.Value()
+				item.dirty = true
 				m.requestsList.SetItem(idx, item)
 			}
 		}
@@ -377,21 +587,45 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.urlField.CursorEnd()
 		// Sync change to requestsList
 		if idx := m.requestsList.Index(); idx >= 0 {
-			if item, ok := m.requestsList.SelectedItem().(request); ok {
+			if item, ok := m.requestsList.SelectedItem().(request); ok && item.endpoint != m.urlField.Value() {
 				item.endpoint = m.urlField.Value()
+				item.dirty = true
 				m.requestsList.SetItem(idx, item)
 			}
 		}
 		// Parse params from URL and update paramsTable
-		urlVal := m.urlField.Value()
-		paramsQuery := ""
-		if idx := strings.Index(urlVal, "?"); idx != -1 {
-			paramsQuery = urlVal[idx:]
-		}
-		m.paramsTable.SetFromQueryString(paramsQuery)
+		item, _ := m.requestsList.SelectedItem().(request)
+		m.loadParams(m.urlField.Value(), item.directives)
 	case tabContentPanel:
-		// With TABLE
-		if m.activeTab == paramsTab {
+		// Down from the last query param goes to the path variables, up
+		// from the first path variable comes back
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.activeTab == paramsTab && !m.paramsTable.bulkMode {
+			switch {
+			case keyMsg.String() == "down" && !m.pathFocused && len(m.pathTable.Rows) > 0 && m.paramsTable.FocusedRow == len(m.paramsTable.Rows)-1:
+				m.pathFocused = true
+				m.paramsTable.Blur()
+				m.pathTable.focusCell(0, 1)
+				return m, tea.Batch(cmds...)
+			case keyMsg.String() == "up" && m.pathFocused && m.pathTable.FocusedRow == 0:
+				m.pathFocused = false
+				m.pathTable.Blur()
+				m.paramsTable.focusCell(len(m.paramsTable.Rows)-1, 0)
+				return m, tea.Batch(cmds...)
+			}
+		}
+		if m.activeTab == paramsTab && m.pathFocused {
+			m.pathTable.Update(msg, m.tabContentWidth)
+			// Sync the values to the directives of the request
+			if idx := m.requestsList.Index(); idx >= 0 {
+				item, ok := m.requestsList.SelectedItem().(request)
+				directives := WithPathDirectives(item.directives, m.pathTable.PathDirectives())
+				if ok && !slices.Equal(item.directives, directives) {
+					item.directives = directives
+					item.dirty = true
+					m.requestsList.SetItem(idx, item)
+				}
+			}
+		} else if m.activeTab == paramsTab {
 			// Always focus last key when entering params tab, but allow typing in value if FocusedCol == 1
 			if len(m.paramsTable.Rows) > 0 {
 				for i, row := range m.paramsTable.Rows {
@@ -425,28 +659,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			// Sync change to requestsList
 			if idx := m.requestsList.Index(); idx >= 0 {
-				if item, ok := m.requestsList.SelectedItem().(request); ok {
+				item, ok := m.requestsList.SelectedItem().(request)
+				directives := WithParamDirectives(item.directives, m.paramsTable.ParamDirectives())
+				if ok && (item.params != m.paramsTable.ToQueryString() || item.endpoint != m.urlField.Value() || !slices.Equal(item.directives, directives)) {
 					item.params = m.paramsTable.ToQueryString()
 					// item.params = m.tabContent[paramsTab].Value()
 					item.endpoint = m.urlField.Value()
+					item.directives = directives
+					item.dirty = true
 					m.requestsList.SetItem(idx, item)
 				}
 			}
-		} else {
-			if m.activeTab == bodyTab {
-				m.bodyArea.Focus()
-				m.bodyArea, cmd = m.bodyArea.Update(msg)
-			} else {
-				m.headersArea.Focus()
-				m.headersArea, cmd = m.headersArea.Update(msg)
+		} else if area := m.editorArea(); area != nil {
+			area.Focus()
+			keyMsg, _ := msg.(tea.KeyMsg)
+			switch keyMsg.String() {
+			case "enter":
+				autoIndent(area)
+			case "alt+f":
+				m.formatEditor(false)
+			case "alt+c":
+				m.formatEditor(true)
+			default:
+				*area, cmd = area.Update(msg)
+				cmds = append(cmds, cmd)
 			}
-			cmds = append(cmds, cmd)
 			if idx := m.requestsList.Index(); idx >= 0 {
 				if item, ok := m.requestsList.SelectedItem().(request); ok {
-					if m.activeTab == bodyTab {
+					if m.activeTab == bodyTab && item.body != m.bodyArea.Value() {
 						item.body = m.bodyArea.Value()
-					} else if m.activeTab == headersTab {
+						item.dirty = true
+					} else if m.activeTab == headersTab && item.headers != m.headersArea.Value() {
 						item.headers = m.headersArea.Value()
+						item.dirty = true
 					}
 					m.requestsList.SetItem(idx, item)
 				}
@@ -474,6 +719,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.responseViewport, cmd = m.responseViewport.Update(msg)
 		cmds = append(cmds, cmd)
 	}
+	m.scopeRequest()
 	// Combine all commands into a single tea.Cmd
 
 	return m, tea.Batch(cmds...)
@@ -517,12 +763,21 @@ func (m Model) View() string {
 		m.bodyArea.Blur()
 		m.headersArea.Blur()
 	}
-	tabView := m.headersArea.View()
+	scope := m.scope
+	tabView := ""
+	if m.activeTab == headersTab {
+		tabView = m.highlightEditor(m.editorView(), scope)
+	}
 	// With TABLE
 	if m.activeTab == 0 {
 		tabView = m.paramsTable.View()
+		if len(m.pathTable.Rows) > 0 {
+			tabView = boldStyle.Render("Query Params") + "\n" + tabView + "\n" + boldStyle.Render("Path Variables") + "\n" + m.pathTable.View()
+		}
 	} else if m.activeTab == 1 {
-		tabView = m.bodyArea.View()
+		tabView = m.highlightEditor(m.editorView(), scope)
+	} else if m.activeTab == variablesTab {
+		tabView = m.variablesView()
 	}
 
 	tabContent := lipgloss.NewStyle().
@@ -585,7 +840,7 @@ func (m Model) View() string {
 		urlValue = ""
 	}
 	m.urlField.SetValue(urlValue)
-	urlInput := urlStyle.Width(urlInputWidth).Height(1).Render(m.urlField.View())
+	urlInput := urlStyle.Width(urlInputWidth).Height(1).Render(m.highlightEditor(m.urlField.View(), scope))
 
 	// Render the Response
 	responseStyle := borderStyle
@@ -605,7 +860,7 @@ func (m Model) View() string {
 		spinnerView := m.spinner.View()
 		responsePanel = responseStyle.Width(m.responseViewport.Width).Height(m.responseViewport.Height).Render(responseTitleStyle.Render(" Response: ") + " " + spinnerView + "\n" + m.responseViewport.View())
 	} else {
-		responsePanel = responseStyle.Width(m.responseViewport.Width).Height(m.responseViewport.Height).Render(responseTitleStyle.Render(" Response: ") + m.statusCode + m.responseTime + "\n" + m.responseViewport.View())
+		responsePanel = responseStyle.Width(m.responseViewport.Width).Height(m.responseViewport.Height).Render(responseTitleStyle.Render(" Response: ") + m.statusCode + m.responseTime + m.responseTabsView() + "\n" + m.responseViewport.View())
 	}
 
 	mainPanel := lipgloss.JoinHorizontal(lipgloss.Left, requestPanel, responsePanel)
@@ -620,6 +875,10 @@ func (m Model) View() string {
 	if m.loading {
 		spinnerView := m.spinner.View()
 		footer = " " + spinnerView + " " + m.appBoundaryMessage(m.message)
+	} else if hint := m.variableHint(scope); hint != "" {
+		footer = m.appBoundaryMessage(hint)
+	} else if hint := m.editorHint(); hint != "" {
+		footer = m.appBoundaryMessage(hint)
 	} else {
 		footer = m.appBoundaryMessage(m.message)
 	}
@@ -635,35 +894,34 @@ func (m *Model) sizeInputs() {
 }
 
 func saveAllToHTTPFile(m Model) error {
+	if m.workspace != "" {
+		return saveWorkspace(m)
+	}
+
 	// Collect all requests from the list
 	var requests []HTTPRequest
-	items := m.requestsList.Items()
-	for i := 0; i < len(items); i++ {
-		item := items[i]
-		if req, ok := item.(request); ok {
-			reqData := HTTPRequest{
-				Name:    req.Title(),
-				Method:  req.Method(),
-				URL:     req.Endpoint(),
-				Headers: req.Headers(),
-				Body:    req.Body(),
-				Params:  req.Params(),
-			}
-			requests = append(requests, reqData)
-		}
+	for _, req := range allRequests(m.requestsList.Items()) {
+		requests = append(requests, req.toHTTP())
 	}
 
-	// Load global variables
-	globalVars := LoadGlobalVarsFromHTTPFile(m.filepath)
-
-	data := &HTTPFileData{
-		Requests:   requests,
-		GlobalVars: globalVars,
-	}
+	// Keep the global variables of the file
+	data, _ := LoadHTTPFile(m.filepath)
+	data.Requests = requests
 	return SaveHTTPFile(data, m.filepath)
 }
 
-// Replace the save() function to also save .http file
-func saveFile(m Model) {
-	_ = saveAllToHTTPFile(m)
+// saveWorkspace writes each request back to the file it came from
+func saveWorkspace(m Model) error {
+	byFile := map[string][]HTTPRequest{}
+	for _, req := range allRequests(m.requestsList.Items()) {
+		byFile[req.file] = append(byFile[req.file], req.toHTTP())
+	}
+	for _, file := range workspaceFiles(m.requestsList.Items()) {
+		data, _ := LoadHTTPFile(file)
+		data.Requests = byFile[file]
+		if err := SaveHTTPFile(data, file); err != nil {
+			return err
+		}
+	}
+	return nil
 }
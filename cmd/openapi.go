@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPISpec is the part of an OpenAPI 3 document postbear uses
+type OpenAPISpec struct {
+	OpenAPI    string                      `yaml:"openapi"`
+	Info       OpenAPIInfo                 `yaml:"info"`
+	Servers    []OpenAPIServer             `yaml:"servers"`
+	Paths      map[string]*OpenAPIPathItem `yaml:"paths"`
+	Components OpenAPIComponents           `yaml:"components"`
+	Security   []map[string][]string       `yaml:"security"`
+	Tags       []OpenAPITag                `yaml:"tags"`
+	path       string                      // file the spec was loaded from
+	operations []*OpenAPIOperation         // in path then method order
+}
+
+type OpenAPIInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+type OpenAPIServer struct {
+	URL         string `yaml:"url"`
+	Description string `yaml:"description"`
+}
+
+type OpenAPITag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema                `yaml:"schemas"`
+	Parameters      map[string]*OpenAPIParameter      `yaml:"parameters"`
+	RequestBodies   map[string]*OpenAPIRequestBody    `yaml:"requestBodies"`
+	Responses       map[string]*OpenAPIResponse       `yaml:"responses"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `yaml:"securitySchemes"`
+}
+
+type OpenAPIPathItem struct {
+	Parameters []*OpenAPIParameter `yaml:"parameters"`
+	Get        *OpenAPIOperation   `yaml:"get"`
+	Put        *OpenAPIOperation   `yaml:"put"`
+	Post       *OpenAPIOperation   `yaml:"post"`
+	Delete     *OpenAPIOperation   `yaml:"delete"`
+	Options    *OpenAPIOperation   `yaml:"options"`
+	Head       *OpenAPIOperation   `yaml:"head"`
+	Patch      *OpenAPIOperation   `yaml:"patch"`
+}
+
+// OpenAPIOperation is one method of a path
+type OpenAPIOperation struct {
+	OperationID string                      `yaml:"operationId"`
+	Summary     string                      `yaml:"summary"`
+	Tags        []string                    `yaml:"tags"`
+	Parameters  []*OpenAPIParameter         `yaml:"parameters"`
+	RequestBody *OpenAPIRequestBody         `yaml:"requestBody"`
+	Responses   map[string]*OpenAPIResponse `yaml:"responses"`
+	Security    *[]map[string][]string      `yaml:"security"` // nil inherits the document security
+	Method      string                      `yaml:"-"`
+	Path        string                      `yaml:"-"`
+	PathParams  []*OpenAPIParameter         `yaml:"-"` // parameters declared on the path, shared by its methods
+}
+
+type OpenAPIParameter struct {
+	Ref      string      `yaml:"$ref"`
+	Name     string      `yaml:"name"`
+	In       string      `yaml:"in"`
+	Required bool        `yaml:"required"`
+	Schema   *Schema     `yaml:"schema"`
+	Example  interface{} `yaml:"example"`
+}
+
+type OpenAPIRequestBody struct {
+	Ref      string                       `yaml:"$ref"`
+	Required bool                         `yaml:"required"`
+	Content  map[string]*OpenAPIMediaType `yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Ref         string                       `yaml:"$ref"`
+	Description string                       `yaml:"description"`
+	Headers     map[string]*OpenAPIHeader    `yaml:"headers"`
+	Content     map[string]*OpenAPIMediaType `yaml:"content"`
+}
+
+type OpenAPIHeader struct {
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+type OpenAPIMediaType struct {
+	Schema   *Schema     `yaml:"schema"`
+	Example  interface{} `yaml:"example"`
+	Examples map[string]struct {
+		Value interface{} `yaml:"value"`
+	} `yaml:"examples"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type   string `yaml:"type"` // http, apiKey, oauth2 or openIdConnect
+	Scheme string `yaml:"scheme"`
+	Name   string `yaml:"name"`
+	In     string `yaml:"in"`
+}
+
+// Schema is a JSON Schema as written in OpenAPI
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 schemaType         `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Nullable             bool               `yaml:"nullable"`
+	Enum                 []interface{}      `yaml:"enum"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	AdditionalProperties *additionalProps   `yaml:"additionalProperties"`
+	Items                *Schema            `yaml:"items"`
+	AllOf                []*Schema          `yaml:"allOf"`
+	OneOf                []*Schema          `yaml:"oneOf"`
+	AnyOf                []*Schema          `yaml:"anyOf"`
+	Minimum              *float64           `yaml:"minimum"`
+	Maximum              *float64           `yaml:"maximum"`
+	MinLength            *int               `yaml:"minLength"`
+	MaxLength            *int               `yaml:"maxLength"`
+	MinItems             *int               `yaml:"minItems"`
+	MaxItems             *int               `yaml:"maxItems"`
+	Pattern              string             `yaml:"pattern"`
+	Example              interface{}        `yaml:"example"`
+	Default              interface{}        `yaml:"default"`
+}
+
+// schemaType is a type name, or a list of them since OpenAPI 3.1
+type schemaType []string
+
+func (t *schemaType) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = schemaType{node.Value}
+		return nil
+	}
+	var types []string
+	if err := node.Decode(&types); err != nil {
+		return err
+	}
+	*t = types
+	return nil
+}
+
+func (t schemaType) has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// additionalProps is either a boolean or the schema of the extra properties
+type additionalProps struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *additionalProps) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Allowed)
+	}
+	a.Allowed = true
+	return node.Decode(&a.Schema)
+}
+
+// LoadOpenAPI reads an OpenAPI 3 document, YAML or JSON
+func LoadOpenAPI(path string) (*OpenAPISpec, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &OpenAPISpec{path: path}
+	if err := yaml.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s is not an OpenAPI 3 document", path)
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for p := range spec.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		item := spec.Paths[p]
+		if item == nil {
+			continue
+		}
+		for _, op := range []struct {
+			method string
+			op     *OpenAPIOperation
+		}{
+			{"GET", item.Get}, {"POST", item.Post}, {"PUT", item.Put}, {"PATCH", item.Patch},
+			{"DELETE", item.Delete}, {"HEAD", item.Head}, {"OPTIONS", item.Options},
+		} {
+			if op.op == nil {
+				continue
+			}
+			op.op.Method = op.method
+			op.op.Path = p
+			op.op.PathParams = item.Parameters
+			spec.operations = append(spec.operations, op.op)
+		}
+	}
+	return spec, nil
+}
+
+// Operations lists the operations of the spec, sorted by path then method
+func (s *OpenAPISpec) Operations() []*OpenAPIOperation {
+	return s.operations
+}
+
+// Schema follows the $ref of a schema, if any
+func (s *OpenAPISpec) Schema(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		schema = s.Components.Schemas[refName(schema.Ref, "schemas")]
+	}
+	return schema
+}
+
+// Parameters returns the parameters of an operation, those of its path
+// included, their $refs followed
+func (s *OpenAPISpec) Parameters(op *OpenAPIOperation) []*OpenAPIParameter {
+	var params []*OpenAPIParameter
+	seen := map[string]bool{}
+	// The operation parameters override the path ones
+	for _, list := range [][]*OpenAPIParameter{op.Parameters, op.PathParams} {
+		for _, p := range list {
+			if p != nil && p.Ref != "" {
+				p = s.Components.Parameters[refName(p.Ref, "parameters")]
+			}
+			if p == nil || seen[p.In+"\x00"+p.Name] {
+				continue
+			}
+			seen[p.In+"\x00"+p.Name] = true
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// RequestBody returns the request body of an operation, its $ref followed
+func (s *OpenAPISpec) RequestBody(op *OpenAPIOperation) *OpenAPIRequestBody {
+	body := op.RequestBody
+	if body != nil && body.Ref != "" {
+		body = s.Components.RequestBodies[refName(body.Ref, "requestBodies")]
+	}
+	return body
+}
+
+// response returns the response of an operation declared for a status
+// code, trying "404", "4XX" then "default"
+func (s *OpenAPISpec) response(op *OpenAPIOperation, status int) (*OpenAPIResponse, bool) {
+	code := fmt.Sprint(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if resp, ok := op.Responses[key]; ok {
+			if resp != nil && resp.Ref != "" {
+				resp = s.Components.Responses[refName(resp.Ref, "responses")]
+			}
+			return resp, true
+		}
+	}
+	return nil, false
+}
+
+// refName is the component name of a local reference like
+// "#/components/schemas/User"
+func refName(ref, kind string) string {
+	return strings.TrimPrefix(ref, "#/components/"+kind+"/")
+}
+
+// basePaths are the path prefixes of the servers of the spec
+func (s *OpenAPISpec) basePaths() []string {
+	var bases []string
+	for _, server := range s.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil || strings.Contains(u.Path, "{") {
+			continue
+		}
+		if base := strings.TrimSuffix(u.Path, "/"); base != "" {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
+
+// MatchOperation finds the operation answering a method and URL. Literal
+// path segments win over {parameters}.
+func (s *OpenAPISpec) MatchOperation(method, rawURL string) *OpenAPIOperation {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	candidates := []string{u.Path}
+	for _, base := range s.basePaths() {
+		if strings.HasPrefix(u.Path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(u.Path, base))
+		}
+	}
+
+	var best *OpenAPIOperation
+	bestParams := -1
+	for _, op := range s.operations {
+		if op.Method != strings.ToUpper(method) {
+			continue
+		}
+		for _, p := range candidates {
+			params, ok := matchTemplate(op.Path, p)
+			if ok && (best == nil || params < bestParams) {
+				best, bestParams = op, params
+			}
+		}
+	}
+	return best
+}
+
+// matchTemplate matches a path against a template like "/users/{id}" and
+// returns the number of parameter segments used
+func matchTemplate(template, path string) (int, bool) {
+	want := splitPath(template)
+	got := splitPath(path)
+	if len(want) != len(got) {
+		return 0, false
+	}
+	params := 0
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params++
+			continue
+		}
+		if segment != got[i] {
+			return 0, false
+		}
+	}
+	return params, true
+}
+
+// contractSpecVar is the variable pointing the requests of a file at their
+// OpenAPI spec, set per environment or as a global variable of the file
+const contractSpecVar = "openapiSpec"
+
+// specFileNames are looked for next to the .http file when no spec is set
+var specFileNames = []string{"openapi.yaml", "openapi.yml", "openapi.json"}
+
+// FindOpenAPISpec returns the OpenAPI spec of the requests of a .http file,
+// "" when there is none
+func FindOpenAPISpec(httpFile string, vars map[string]string) string {
+	dir := filepath.Dir(httpFilePath(httpFile))
+	if spec := vars[contractSpecVar]; spec != "" {
+		if !filepath.IsAbs(spec) {
+			spec = filepath.Join(dir, spec)
+		}
+		return spec
+	}
+	for _, name := range specFileNames {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sahilm/fuzzy"
+)
+
+// paletteAction is an entry of the command palette, running it replays
+// its key on the main model
+type paletteAction struct {
+	name  string
+	key   tea.KeyMsg
+	focus int   // panel to focus before replaying the key, -1 to keep the current one
+	tabs  []int // tabs the key works in, the first is shown when the active tab is none of them
+}
+
+var paletteActions = []paletteAction{
+	{name: "Send Request", key: tea.KeyMsg{Type: tea.KeyEnter}, focus: urlFieldPanel},
+	{name: "Save Requests", key: tea.KeyMsg{Type: tea.KeyCtrlS}, focus: -1},
+	{name: "New Request", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")}, focus: requestsListPanel},
+	{name: "Remove Request", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}, focus: requestsListPanel},
+	{name: "Duplicate Request", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")}, focus: requestsListPanel},
+	{name: "Rename Request", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")}, focus: requestsListPanel},
+	{name: "Mark Request", key: tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, focus: requestsListPanel},
+	{name: "Move Request Up", key: tea.KeyMsg{Type: tea.KeyShiftUp}, focus: requestsListPanel},
+	{name: "Move Request Down", key: tea.KeyMsg{Type: tea.KeyShiftDown}, focus: requestsListPanel},
+	{name: "Undo", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")}, focus: requestsListPanel},
+	{name: "Prettify", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f"), Alt: true}, focus: tabContentPanel, tabs: []int{bodyTab, headersTab}},
+	{name: "Minify", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c"), Alt: true}, focus: tabContentPanel, tabs: []int{bodyTab, headersTab}},
+	{name: "Bulk Edit Params", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b"), Alt: true}, focus: tabContentPanel, tabs: []int{paramsTab}},
+	{name: "Run Collection", key: tea.KeyMsg{Type: tea.KeyCtrlR}, focus: -1},
+	{name: "Diff Responses", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true}, focus: -1},
+	{name: "Benchmark Request", key: tea.KeyMsg{Type: tea.KeyCtrlB}, focus: -1},
+	{name: "Quick Open Request", key: tea.KeyMsg{Type: tea.KeyCtrlP}, focus: -1},
+	{name: "Environment Variables", key: tea.KeyMsg{Type: tea.KeyCtrlE}, focus: -1},
+	{name: "Switch Environment", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n"), Alt: true}, focus: -1},
+	{name: "Export Request as curl", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true}, focus: -1},
+	{name: "Unlock Secrets", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u"), Alt: true}, focus: -1},
+	{name: "Toggle Autosave", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s"), Alt: true}, focus: -1},
+	{name: "Reload File Changed On Disk", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r"), Alt: true}, focus: -1},
+	{name: "Merge File Changed On Disk", key: tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m"), Alt: true}, focus: -1},
+	{name: "Next Tab", key: tea.KeyMsg{Type: tea.KeyShiftRight}, focus: -1},
+	{name: "Previous Tab", key: tea.KeyMsg{Type: tea.KeyShiftLeft}, focus: -1},
+	{name: "Help", key: tea.KeyMsg{Type: tea.KeyCtrlH}, focus: -1},
+	{name: "Quit", key: tea.KeyMsg{Type: tea.KeyCtrlC}, focus: -1},
+}
+
+type paletteEntry struct {
+	label  string // rendered text
+	search string // text matched against the query
+}
+
+type palette struct {
+	width       int
+	height      int
+	styles      *Styles
+	returnModel Model
+	title       string
+	input       textinput.Model
+	entries     []paletteEntry
+	matches     fuzzy.Matches
+	cursor      int
+	onSelect    func(m Model, index int) (tea.Model, tea.Cmd)
+}
+
+func newPalette(m Model, title string, entries []paletteEntry, onSelect func(Model, int) (tea.Model, tea.Cmd)) palette {
+	p := palette{
+		width:       m.width,
+		height:      m.height,
+		styles:      m.styles,
+		returnModel: m,
+		title:       title,
+		input:       textinput.New(),
+		entries:     entries,
+		onSelect:    onSelect,
+	}
+	p.input.Prompt = "> "
+	p.input.Placeholder = "Type to search"
+	p.input.Focus()
+	p.filter()
+	return p
+}
+
+// quickOpen lists every request of every loaded file
+func quickOpen(m Model) palette {
+	requests := allRequests(m.requestsList.Items())
+	var entries []paletteEntry
+	for _, req := range requests {
+		file := ""
+		if req.file != "" {
+			file = filepath.Base(req.file)
+		}
+		entries = append(entries, paletteEntry{
+			label:  fmt.Sprintf("%-6s %s  %s", req.Method(), req.Title(), NormalTitleStyle.Render(strings.TrimSpace(req.Endpoint()+"  "+file))),
+			search: req.Method() + " " + req.Title() + " " + req.Endpoint() + " " + file,
+		})
+	}
+	return newPalette(m, "POSTBEAR Quick Open", entries, func(m Model, index int) (tea.Model, tea.Cmd) {
+		revealRequest(&m.requestsList, index)
+		m.focused = requestsListPanel
+		// Let the list sync the fields with the new selection
+		return m.Update(nil)
+	})
+}
+
+// environmentPalette lists the environments of http-client.env.json, the
+// selected one resolves the {{variables}} from then on
+func environmentPalette(m Model) palette {
+	names := append([]string{""}, EnvironmentNames(m.filepath)...)
+	var entries []paletteEntry
+	for _, name := range names {
+		label := name
+		if name == "" {
+			label = "none"
+		}
+		search := label
+		if name == m.environment {
+			label += NormalTitleStyle.Render("  (current)")
+		}
+		entries = append(entries, paletteEntry{label: label, search: search})
+	}
+	return newPalette(m, "POSTBEAR Environments", entries, func(m Model, index int) (tea.Model, tea.Cmd) {
+		m = m.WithEnvironment(names[index])
+		m.message = m.appBoundaryMessage("Environment: " + entries[index].search)
+		return m, nil
+	})
+}
+
+// commandPalette lists every action with its keybinding
+func commandPalette(m Model) palette {
+	var entries []paletteEntry
+	for _, action := range paletteActions {
+		key := action.key.String()
+		if key == " " {
+			key = "space"
+		}
+		entries = append(entries, paletteEntry{
+			label:  fmt.Sprintf("%-24s %s", action.name, NormalTitleStyle.Render(key)),
+			search: action.name,
+		})
+	}
+	return newPalette(m, "POSTBEAR Commands", entries, func(m Model, index int) (tea.Model, tea.Cmd) {
+		action := paletteActions[index]
+		if action.focus >= 0 {
+			m.focused = action.focus
+		}
+		if len(action.tabs) > 0 {
+			if !slices.Contains(action.tabs, m.activeTab) {
+				m.activeTab = action.tabs[0]
+			}
+			// The path variables have no bulk mode
+			m.pathFocused = false
+		}
+		return m.Update(action.key)
+	})
+}
+
+// revealRequest selects the n-th request of the list, expanding the
+// collapsed nodes that hide it
+func revealRequest(l *list.Model, n int) {
+	count := 0
+	for i := 0; i < len(l.Items()); i++ {
+		switch item := l.Items()[i].(type) {
+		case request:
+			if count == n {
+				l.Select(i)
+				return
+			}
+			count++
+		case treeNode:
+			if !item.collapsed {
+				continue
+			}
+			hidden := len(allRequests(item.hidden))
+			if n < count+hidden {
+				toggleNode(l, i)
+				continue
+			}
+			count += hidden
+		}
+	}
+}
+
+func (p *palette) filter() {
+	query := strings.TrimSpace(p.input.Value())
+	if query == "" {
+		p.matches = make(fuzzy.Matches, len(p.entries))
+		for i := range p.entries {
+			p.matches[i] = fuzzy.Match{Index: i}
+		}
+	} else {
+		var search []string
+		for _, e := range p.entries {
+			search = append(search, e.search)
+		}
+		p.matches = fuzzy.Find(query, search)
+	}
+	p.cursor = max(min(p.cursor, len(p.matches)-1), 0)
+}
+
+func (p palette) Init() tea.Cmd {
+	return nil
+}
+
+func (p palette) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return p.returnModel.confirmQuit()
+		case "esc":
+			p.returnModel.width = p.width
+			p.returnModel.height = p.height
+			return p.returnModel, p.returnModel.restartWatch()
+		case "enter":
+			if len(p.matches) == 0 {
+				return p, nil
+			}
+			p.returnModel.width = p.width
+			p.returnModel.height = p.height
+			watch := p.returnModel.restartWatch()
+			next, cmd := p.onSelect(p.returnModel, p.matches[p.cursor].Index)
+			return next, tea.Batch(watch, cmd)
+		case "up", "ctrl+k":
+			p.cursor = max(p.cursor-1, 0)
+			return p, nil
+		case "down", "ctrl+j":
+			p.cursor = min(p.cursor+1, len(p.matches)-1)
+			return p, nil
+		}
+	}
+	p.input, cmd = p.input.Update(msg)
+	p.filter()
+	return p, cmd
+}
+
+func (p palette) View() string {
+	header := p.appTopLabel(p.title)
+
+	var b strings.Builder
+	b.WriteString(p.input.View() + "\n\n")
+	rows := max(p.height-8, 1)
+	start := max(p.cursor-rows+1, 0)
+	for i := start; i < len(p.matches) && i < start+rows; i++ {
+		line := ansi.Truncate(p.entries[p.matches[i].Index].label, p.width-6, "…")
+		if i == p.cursor {
+			b.WriteString(selectedTitle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if len(p.matches) == 0 {
+		b.WriteString(NormalTitleStyle.Render("  No matches"))
+	}
+
+	body := borderStyle.Width(p.width - 2).Height(p.height - 4).Render(lipgloss.NewStyle().Padding(0, 1).Render(b.String()))
+	footer := p.appBottomLabel("enter to select, up/down to move, <ESC> to go back")
+	return p.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
@@ -1,137 +1,311 @@
 package cmd
 
 import (
-	"fmt"
+	"net/url"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// paramDirective keeps what the URL can't hold of a query param, its
+// description or that it is disabled, as in "# @param !page=2 skipped"
+const paramDirective = "param"
+
 type TableRow struct {
 	KeyInput   textinput.Model
 	ValueInput textinput.Model
+	DescInput  textinput.Model
+	Disabled   bool // left out of ToValues and ToQueryString
+	Secret     bool // the value is masked
 }
 
 type ParamsTable struct {
-	Rows       []TableRow
-	FocusedRow int
-	FocusedCol int // 0 for key, 1 for value
-	width      int
+	Rows         []TableRow
+	FocusedRow   int
+	FocusedCol   int // 0 for key, 1 for value, 2 for description
+	width        int
+	charLimit    int  // of keys and values, 0 for none
+	maxRows      int  // rows AddRow goes up to, 0 for none
+	Toggles      bool // show the enabled checkbox of the rows, alt+e flips it
+	Secrets      bool // ctrl+k makes the value of a row secret
+	Descriptions bool // a third column describes the rows
+	AllowBulk    bool // alt+b edits the rows as text, never with Secrets
+	FixedKeys    bool // only the values are edited, rows are neither added nor removed
+	// Validate returns the error of a row, shown under it, "" when it is valid
+	Validate func(t *ParamsTable, i int) string
+	bulkMode bool           // the rows are edited as text, alt+b switches
+	bulk     textarea.Model // one "key=value // description" line per row
+	blurred  bool           // no cell is focused
 }
 
 func NewParamsTable() ParamsTable {
-	row := TableRow{
-		KeyInput:   textinput.New(),
-		ValueInput: textinput.New(),
+	t := ParamsTable{maxRows: 10, Toggles: true, Descriptions: true, AllowBulk: true}
+	t.AddRow()
+	return t
+}
+
+// NewVariablesTable is a table of variables, with the enabled and secret
+// flags of each row
+func NewVariablesTable() ParamsTable {
+	t := ParamsTable{Toggles: true, Secrets: true}
+	t.AddRow()
+	return t
+}
+
+// columnWidths returns the widths of the checkbox, key, value and
+// description columns
+func (t *ParamsTable) columnWidths() (int, int, int, int) {
+	flags, columns := 0, 2
+	if t.Toggles {
+		flags = 4
 	}
-	row.KeyInput.Placeholder = "Key"
-	row.ValueInput.Placeholder = "Value"
-	row.KeyInput.CharLimit = 20
-	row.ValueInput.CharLimit = 20
-	row.KeyInput.Focus()
-	return ParamsTable{
-		Rows:       []TableRow{row},
-		FocusedRow: 0,
-		FocusedCol: 0,
-		width:      0,
+	if t.Descriptions {
+		columns = 3
 	}
+	cell := (t.width - flags) / columns
+	if !t.Descriptions {
+		return flags, cell, cell, 0
+	}
+	return flags, cell, cell, cell
 }
 
-func (t *ParamsTable) AddRow() {
-	if len(t.Rows) >= 10 {
-		return
+// sizeInputs makes long keys and values scroll inside their cells
+func (t *ParamsTable) sizeInputs() {
+	_, key, value, desc := t.columnWidths()
+	for i := range t.Rows {
+		// Room for the prompt and the cursor
+		t.Rows[i].KeyInput.Width = max(key-3, 1)
+		t.Rows[i].ValueInput.Width = max(value-3, 1)
+		t.Rows[i].DescInput.Width = max(desc-3, 1)
 	}
+}
+
+func (t *ParamsTable) newRow(key, value string) TableRow {
 	row := TableRow{
 		KeyInput:   textinput.New(),
 		ValueInput: textinput.New(),
+		DescInput:  textinput.New(),
 	}
 	row.KeyInput.Placeholder = "Key"
 	row.ValueInput.Placeholder = "Value"
-	row.KeyInput.CharLimit = 20
-	row.ValueInput.CharLimit = 20
+	row.DescInput.Placeholder = "Description"
+	row.KeyInput.CharLimit = t.charLimit
+	row.ValueInput.CharLimit = t.charLimit
+	row.KeyInput.SetValue(key)
+	row.ValueInput.SetValue(value)
+	return row
+}
+
+func (t *ParamsTable) AddRow() {
+	if t.maxRows > 0 && len(t.Rows) >= t.maxRows {
+		return
+	}
+	t.Rows = append(t.Rows, t.newRow("", ""))
+	t.sizeInputs()
+	t.focusCell(len(t.Rows)-1, 0)
+}
+
+// AppendRow adds a filled row at the end, without moving the focus
+func (t *ParamsTable) AppendRow(key, value string, disabled, secret bool) {
+	row := t.newRow(key, value)
+	row.Disabled = disabled
 	t.Rows = append(t.Rows, row)
-	t.FocusedRow = len(t.Rows) - 1
-	t.FocusedCol = 0
-	t.Rows[t.FocusedRow].KeyInput.Focus()
+	t.setSecret(len(t.Rows)-1, secret)
+	t.sizeInputs()
+}
+
+// focusCell moves the focus to a column of a row
+func (t *ParamsTable) focusCell(row, col int) {
+	t.Blur()
+	t.blurred = false
+	if t.FixedKeys {
+		col = 1
+	}
+	t.FocusedRow, t.FocusedCol = row, col
+	input := t.cell(row, col)
+	input.Focus()
+	input.Cursor.Blink = true
+}
+
+// Blur leaves the table without a focused cell, until the next focusCell
+func (t *ParamsTable) Blur() {
+	t.blurred = true
+	for i := range t.Rows {
+		t.Rows[i].KeyInput.Blur()
+		t.Rows[i].ValueInput.Blur()
+		t.Rows[i].DescInput.Blur()
+	}
+}
+
+func (t *ParamsTable) cell(row, col int) *textinput.Model {
+	switch col {
+	case 1:
+		return &t.Rows[row].ValueInput
+	case 2:
+		return &t.Rows[row].DescInput
+	}
+	return &t.Rows[row].KeyInput
+}
+
+// DeleteRow removes the focused row, an empty one is left in an empty table
+func (t *ParamsTable) DeleteRow() {
+	if len(t.Rows) == 0 {
+		return
+	}
+	t.Rows = append(t.Rows[:t.FocusedRow], t.Rows[t.FocusedRow+1:]...)
+	if len(t.Rows) == 0 {
+		t.AddRow()
+		return
+	}
+	t.focusCell(min(t.FocusedRow, len(t.Rows)-1), 0)
+}
+
+// MoveRow moves the focused row up (-1) or down (1)
+func (t *ParamsTable) MoveRow(delta int) {
+	to := t.FocusedRow + delta
+	if to < 0 || to >= len(t.Rows) {
+		return
+	}
+	t.Rows[t.FocusedRow], t.Rows[to] = t.Rows[to], t.Rows[t.FocusedRow]
+	t.focusCell(to, t.FocusedCol)
+}
+
+func (t *ParamsTable) setSecret(i int, secret bool) {
+	t.Rows[i].Secret = secret
+	t.Rows[i].ValueInput.EchoMode = textinput.EchoNormal
+	if secret {
+		t.Rows[i].ValueInput.EchoMode = textinput.EchoPassword
+	}
+}
+
+// Invalid returns the first row Validate reports an error for, -1 when
+// they are all valid
+func (t *ParamsTable) Invalid() int {
+	if t.Validate == nil {
+		return -1
+	}
+	for i := range t.Rows {
+		if t.Validate(t, i) != "" {
+			return i
+		}
+	}
+	return -1
 }
 
 func (t *ParamsTable) Update(msg tea.Msg, width int) {
 	t.width = width
+	t.sizeInputs()
+	if t.FixedKeys {
+		t.updateValues(msg)
+		return
+	}
 	if len(t.Rows) == 0 {
 		t.AddRow()
 	}
-	row := &t.Rows[t.FocusedRow]
-	if t.FocusedCol == 0 {
-		row.KeyInput, _ = row.KeyInput.Update(msg)
-	} else {
-		row.ValueInput, _ = row.ValueInput.Update(msg)
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.String() == "alt+b" && t.AllowBulk {
+		t.SetBulk(!t.bulkMode)
+		return
+	}
+	if t.bulkMode {
+		t.bulk, _ = t.bulk.Update(msg)
+		t.setBulkText(t.bulk.Value())
+		return
+	}
+	// Row commands don't reach the inputs
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "ctrl+d":
+			t.DeleteRow()
+			return
+		case "shift+up":
+			t.MoveRow(-1)
+			return
+		case "shift+down":
+			t.MoveRow(1)
+			return
+		case "alt+e":
+			if t.Toggles {
+				t.Rows[t.FocusedRow].Disabled = !t.Rows[t.FocusedRow].Disabled
+				return
+			}
+		case "ctrl+k":
+			if t.Secrets {
+				t.setSecret(t.FocusedRow, !t.Rows[t.FocusedRow].Secret)
+				return
+			}
+		}
 	}
+	row := &t.Rows[t.FocusedRow]
+	input := t.cell(t.FocusedRow, t.FocusedCol)
+	*input, _ = input.Update(msg)
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		switch keyMsg.String() {
 		case "enter":
-			if t.FocusedCol == 0 {
-				// If key is focused, move to value
-				t.FocusedCol = 1
-				row.ValueInput.Focus()
-				row.KeyInput.Blur()
+			if t.FocusedCol == 0 || (t.FocusedCol == 1 && t.Descriptions) {
+				// Move to the next cell of the row
+				t.focusCell(t.FocusedRow, t.FocusedCol+1)
 			} else {
-				// If value is focused, add new row
+				// From the last cell, add new row
 				if row.KeyInput.Value() != "" || row.ValueInput.Value() != "" {
-					row.KeyInput.Blur()
 					t.AddRow()
 				}
 			}
 		case "up":
 			if t.FocusedRow > 0 {
-				// Remove previous focus
-				prevRow := &t.Rows[t.FocusedRow]
-				prevRow.KeyInput.Blur()
-				prevRow.ValueInput.Blur()
-				t.FocusedRow--
-				t.FocusedCol = 0
-				row := &t.Rows[t.FocusedRow]
-				row.KeyInput.Focus()
-				row.ValueInput.Blur()
-				row.KeyInput.Cursor.Blink = true
+				t.focusCell(t.FocusedRow-1, 0)
 			}
 		case "down":
 			if t.FocusedRow < len(t.Rows)-1 {
-				// Remove previous focus
-				prevRow := &t.Rows[t.FocusedRow]
-				prevRow.KeyInput.Blur()
-				prevRow.ValueInput.Blur()
-				t.FocusedRow++
-				t.FocusedCol = 0
-				row := &t.Rows[t.FocusedRow]
-				row.KeyInput.Focus()
-				row.ValueInput.Blur()
-				row.KeyInput.Cursor.Blink = true
+				t.focusCell(t.FocusedRow+1, 0)
 			}
 		}
 	}
 }
 
-func (t *ParamsTable) ToMap() map[string]string {
-	m := make(map[string]string)
+// updateValues edits the values of a table with FixedKeys, up and down
+// move between the rows
+func (t *ParamsTable) updateValues(msg tea.Msg) {
+	if len(t.Rows) == 0 {
+		return
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "up":
+			t.focusCell(max(t.FocusedRow-1, 0), 1)
+			return
+		case "down", "enter":
+			t.focusCell(min(t.FocusedRow+1, len(t.Rows)-1), 1)
+			return
+		}
+	}
+	t.Rows[t.FocusedRow].ValueInput, _ = t.Rows[t.FocusedRow].ValueInput.Update(msg)
+}
+
+// ToValues returns the enabled rows, repeated keys keep all their values
+func (t *ParamsTable) ToValues() url.Values {
+	values := url.Values{}
 	for _, row := range t.Rows {
 		k := strings.TrimSpace(row.KeyInput.Value())
 		v := strings.TrimSpace(row.ValueInput.Value())
-		if k != "" {
-			m[k] = v
+		if k != "" && !row.Disabled {
+			values.Add(k, v)
 		}
 	}
-	return m
+	return values
 }
 
+// ToQueryString encodes the enabled rows in order, the {{variables}} are
+// kept as written
 func (t *ParamsTable) ToQueryString() string {
 	var params []string
 	for _, row := range t.Rows {
 		k := strings.TrimSpace(row.KeyInput.Value())
 		v := strings.TrimSpace(row.ValueInput.Value())
-		if k != "" {
-			params = append(params, fmt.Sprintf("%s=%s", k, v))
+		if k != "" && !row.Disabled {
+			params = append(params, encodeQueryComponent(k)+"="+encodeQueryComponent(v))
 		}
 	}
 	if len(params) == 0 {
@@ -140,6 +314,30 @@ func (t *ParamsTable) ToQueryString() string {
 	return "?" + strings.Join(params, "&")
 }
 
+// encodeQueryComponent percent-encodes s outside of its {{variables}},
+// spaces as %20
+func encodeQueryComponent(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(s, -1) {
+		sb.WriteString(strings.ReplaceAll(url.QueryEscape(s[last:loc[0]]), "+", "%20"))
+		sb.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(strings.ReplaceAll(url.QueryEscape(s[last:]), "+", "%20"))
+	return sb.String()
+}
+
+// decodeQueryComponent is the reverse of encodeQueryComponent, a value
+// that isn't valid encoding is kept as it is
+func decodeQueryComponent(s string) string {
+	decoded, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
 func (t *ParamsTable) SetFromQueryString(query string) {
 	t.Rows = nil
 	if strings.HasPrefix(query, "?") {
@@ -154,48 +352,232 @@ func (t *ParamsTable) SetFromQueryString(query string) {
 		key := ""
 		val := ""
 		if len(kv) > 0 {
-			key = kv[0]
+			key = decodeQueryComponent(kv[0])
 		}
 		if len(kv) > 1 {
-			val = kv[1]
+			val = decodeQueryComponent(kv[1])
 		}
-		row := TableRow{
-			KeyInput:   textinput.New(),
-			ValueInput: textinput.New(),
-		}
-		row.KeyInput.Placeholder = "Key"
-		row.ValueInput.Placeholder = "Value"
-		row.KeyInput.CharLimit = 20
-		row.ValueInput.CharLimit = 20
-		row.KeyInput.SetValue(key)
-		row.ValueInput.SetValue(val)
-		t.Rows = append(t.Rows, row)
+		t.Rows = append(t.Rows, t.newRow(key, val))
 	}
 	if len(t.Rows) == 0 {
 		t.AddRow()
 	}
+	t.sizeInputs()
 	// Always focus last key
-	t.FocusedRow = len(t.Rows) - 1
-	t.FocusedCol = 0
-	t.Rows[t.FocusedRow].KeyInput.Focus()
-	t.Rows[t.FocusedRow].ValueInput.Blur()
-	t.Rows[t.FocusedRow].KeyInput.Cursor.Blink = true
+	t.focusCell(len(t.Rows)-1, 0)
+	t.refreshBulk()
+}
+
+// ParamDirectives returns the "param" directives of the disabled rows and
+// of the ones with a description
+func (t *ParamsTable) ParamDirectives() []string {
+	var directives []string
+	for _, row := range t.Rows {
+		k := strings.TrimSpace(row.KeyInput.Value())
+		desc := strings.TrimSpace(row.DescInput.Value())
+		if k == "" || (!row.Disabled && desc == "") {
+			continue
+		}
+		d := paramDirective + " "
+		if row.Disabled {
+			d += "!"
+		}
+		d += encodeQueryComponent(k) + "=" + encodeQueryComponent(strings.TrimSpace(row.ValueInput.Value()))
+		if desc != "" {
+			d += " " + desc
+		}
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// SetParamDirectives gives the rows taken from the URL their descriptions,
+// and appends the disabled rows
+func (t *ParamsTable) SetParamDirectives(directives []string) {
+	used := map[int]bool{}
+	for _, d := range directives {
+		name, rest, _ := strings.Cut(d, " ")
+		if name != paramDirective {
+			continue
+		}
+		pair, desc := splitParamDirective(rest)
+		disabled := strings.HasPrefix(pair, "!")
+		kv := strings.SplitN(strings.TrimPrefix(pair, "!"), "=", 2)
+		key, value := decodeQueryComponent(kv[0]), ""
+		if len(kv) > 1 {
+			value = decodeQueryComponent(kv[1])
+		}
+		if disabled {
+			if len(t.Rows) == 1 && t.Rows[0].KeyInput.Value() == "" && t.Rows[0].ValueInput.Value() == "" {
+				t.Rows = nil
+			}
+			t.AppendRow(key, value, true, false)
+			t.Rows[len(t.Rows)-1].DescInput.SetValue(desc)
+			continue
+		}
+		for i, row := range t.Rows {
+			if !used[i] && !row.Disabled && row.KeyInput.Value() == key && row.ValueInput.Value() == value {
+				used[i] = true
+				t.Rows[i].DescInput.SetValue(desc)
+				break
+			}
+		}
+	}
+	t.focusCell(min(t.FocusedRow, len(t.Rows)-1), 0)
+	t.refreshBulk()
+}
+
+// splitParamDirective splits "key=value description", the spaces of the
+// {{variables}} of the value don't end it
+func splitParamDirective(s string) (string, string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			depth++
+			i++
+		case strings.HasPrefix(s[i:], "}}") && depth > 0:
+			depth--
+			i++
+		case s[i] == ' ' && depth == 0:
+			return s[:i], strings.TrimSpace(s[i+1:])
+		}
+	}
+	return s, ""
+}
+
+// WithParamDirectives replaces the "param" directives of a request
+func WithParamDirectives(directives, params []string) []string {
+	return replaceDirectives(directives, paramDirective, params)
+}
+
+// replaceDirectives replaces the directives called name with replacement
+func replaceDirectives(directives []string, name string, replacement []string) []string {
+	var kept []string
+	for _, d := range directives {
+		if n, _, _ := strings.Cut(d, " "); n != name {
+			kept = append(kept, d)
+		}
+	}
+	return append(kept, replacement...)
+}
+
+// SetBulk switches between the table and the text editing of the rows
+func (t *ParamsTable) SetBulk(bulk bool) {
+	if bulk && !t.AllowBulk {
+		return
+	}
+	t.bulkMode = bulk
+	if bulk {
+		t.bulk = newTextarea()
+		t.bulk.ShowLineNumbers = false
+		t.bulk.SetWidth(max(t.width, 10))
+		t.bulk.SetHeight(10)
+		t.refreshBulk()
+		t.bulk.Focus()
+		return
+	}
+	if len(t.Rows) == 0 {
+		t.AddRow()
+	}
+	t.focusCell(0, 0)
+}
+
+// BulkText returns the rows as "key=value // description" lines, the
+// disabled ones commented out with #
+func (t *ParamsTable) BulkText() string {
+	var lines []string
+	for _, row := range t.Rows {
+		k := strings.TrimSpace(row.KeyInput.Value())
+		v := strings.TrimSpace(row.ValueInput.Value())
+		if k == "" && v == "" {
+			continue
+		}
+		line := k + "=" + v
+		if row.Disabled {
+			line = "# " + line
+		}
+		if desc := strings.TrimSpace(row.DescInput.Value()); desc != "" {
+			line += " // " + desc
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *ParamsTable) refreshBulk() {
+	if t.bulkMode {
+		t.bulk.SetValue(t.BulkText())
+	}
+}
+
+// setBulkText replaces the rows with the lines of the bulk editor
+func (t *ParamsTable) setBulkText(text string) {
+	t.Rows = nil
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		disabled := strings.HasPrefix(line, "#")
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		line, desc, _ := strings.Cut(line, " // ")
+		key, value, _ := strings.Cut(line, "=")
+		t.AppendRow(strings.TrimSpace(key), strings.TrimSpace(value), disabled, false)
+		t.Rows[len(t.Rows)-1].DescInput.SetValue(strings.TrimSpace(desc))
+	}
+	if len(t.Rows) == 0 {
+		t.Rows = append(t.Rows, t.newRow("", ""))
+	}
+	t.FocusedRow, t.FocusedCol = 0, 0
 }
 
 func (t *ParamsTable) View() string {
+	if t.bulkMode {
+		return t.bulk.View() + "\n" + variableSourceStyle.Render("key=value // description, one per line, # disables a line, alt+b for the table")
+	}
 	var b strings.Builder
+	flagsWidth, keyWidth, valueWidth, descWidth := t.columnWidths()
 	for i, row := range t.Rows {
-		keyStyle := lipgloss.NewStyle().Width(((t.width) / 2)).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(indigo)
-		valueStyle := lipgloss.NewStyle().Width(((t.width) / 2)).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(indigo)
-		if i == t.FocusedRow && t.FocusedCol == 0 {
+		keyStyle := lipgloss.NewStyle().Width(keyWidth).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(indigo)
+		valueStyle := lipgloss.NewStyle().Width(valueWidth).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(indigo)
+		descStyle := lipgloss.NewStyle().Width(descWidth).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(indigo).Faint(true)
+		if !t.blurred && i == t.FocusedRow && t.FocusedCol == 0 {
 			keyStyle = keyStyle.BorderForeground(green)
 		}
-		if i == t.FocusedRow && t.FocusedCol == 1 {
+		if !t.blurred && i == t.FocusedRow && t.FocusedCol == 1 {
 			valueStyle = valueStyle.BorderForeground(green)
 		}
-		keyView := keyStyle.Render(row.KeyInput.View())
-		valueView := valueStyle.Render(row.ValueInput.View())
-		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, keyView, valueView) + "\n")
+		if !t.blurred && i == t.FocusedRow && t.FocusedCol == 2 {
+			descStyle = descStyle.BorderForeground(green)
+		}
+		var rowError string
+		if t.Validate != nil {
+			rowError = t.Validate(t, i)
+		}
+		if rowError != "" {
+			keyStyle = keyStyle.BorderForeground(lipgloss.Color("9"))
+			valueStyle = valueStyle.BorderForeground(lipgloss.Color("9"))
+		}
+		if row.Disabled {
+			keyStyle = keyStyle.Faint(true).Strikethrough(true)
+			valueStyle = valueStyle.Faint(true).Strikethrough(true)
+		}
+		cells := []string{keyStyle.Render(row.KeyInput.View()), valueStyle.Render(row.ValueInput.View())}
+		if t.Descriptions {
+			cells = append(cells, descStyle.Render(row.DescInput.View()))
+		}
+		if t.Toggles {
+			flag := "[x] "
+			if row.Disabled {
+				flag = "[ ] "
+			}
+			cells = append([]string{lipgloss.NewStyle().Width(flagsWidth).Render(flag)}, cells...)
+		}
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, cells...) + "\n")
+		if rowError != "" {
+			b.WriteString(undefinedVariableStyle.Render(strings.Repeat(" ", flagsWidth)+rowError) + "\n")
+		}
 	}
 	return b.String()
 }
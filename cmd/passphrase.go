@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// passphraseScreen asks for the passphrase of the secrets file, for when
+// POSTBEAR_SECRET_PASSPHRASE is not set
+type passphraseScreen struct {
+	width       int
+	height      int
+	styles      *Styles
+	returnModel Model
+	input       textinput.Model
+	err         string
+}
+
+func newPassphraseScreen(m Model) passphraseScreen {
+	s := passphraseScreen{
+		width:       m.width,
+		height:      m.height,
+		styles:      m.styles,
+		returnModel: m,
+		input:       textinput.New(),
+	}
+	s.input.Prompt = "> "
+	s.input.Placeholder = "Passphrase"
+	s.input.EchoMode = textinput.EchoPassword
+	s.input.Focus()
+	return s
+}
+
+func (s passphraseScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s passphraseScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return s.returnModel.confirmQuit()
+		case "esc":
+			s.returnModel.width = s.width
+			s.returnModel.height = s.height
+			return s.returnModel, s.returnModel.restartWatch()
+		case "enter":
+			if err := UnlockSecrets(s.input.Value()); err != nil {
+				s.err = err.Error()
+				s.input.SetValue("")
+				return s, nil
+			}
+			s.returnModel.width = s.width
+			s.returnModel.height = s.height
+			s.returnModel.message = s.returnModel.appBoundaryMessage("Secrets unlocked")
+			return s.returnModel, s.returnModel.restartWatch()
+		}
+	}
+	s.input, cmd = s.input.Update(msg)
+	return s, cmd
+}
+
+func (s passphraseScreen) View() string {
+	header := s.appTopLabel("POSTBEAR Secrets")
+
+	var b strings.Builder
+	b.WriteString("Passphrase of the secrets file, kept until postbear quits\n\n")
+	b.WriteString(s.input.View() + "\n")
+	if s.err != "" {
+		b.WriteString("\n" + undefinedVariableStyle.Render(s.err) + "\n")
+	}
+
+	body := borderStyle.Width(s.width - 2).Height(s.height - 4).Render(lipgloss.NewStyle().Padding(0, 1).Render(b.String()))
+	footer := s.appBottomLabel("enter to unlock, <ESC> to go back")
+	return s.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"net/url"
+	"strings"
+)
+
+// pathDirective keeps the value of a :name or {name} path param of a
+// request, as in "# @path id=42"
+const pathDirective = "path"
+
+// pathBounds returns where the path of a URL starts and ends, the URL may
+// lack a scheme and host, or start with a {{variable}}
+func pathBounds(rawURL string) (int, int) {
+	end := len(rawURL)
+	if i := strings.IndexAny(rawURL, "?#"); i != -1 {
+		end = i
+	}
+	start := 0
+	if i := strings.Index(rawURL[:end], "://"); i != -1 {
+		start = i + 3
+	}
+	if j := strings.Index(rawURL[start:end], "/"); j != -1 {
+		return start + j, end
+	}
+	return end, end
+}
+
+// pathParamName returns the name of a :name or {name} segment, {{name}}
+// is a variable
+func pathParamName(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{{") {
+		return "", false
+	}
+	name, ok := pathParam(segment)
+	return name, ok && variableName.MatchString(name)
+}
+
+// PathParams lists the names of the :name and {name} segments of the path
+// of a URL, in order
+func PathParams(rawURL string) []string {
+	start, end := pathBounds(rawURL)
+	var names []string
+	seen := map[string]bool{}
+	for _, segment := range strings.Split(rawURL[start:end], "/") {
+		if name, ok := pathParamName(segment); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// unresolvedPathParams lists the path param segments of a URL, as written
+func unresolvedPathParams(rawURL string) []string {
+	start, end := pathBounds(rawURL)
+	var segments []string
+	for _, segment := range strings.Split(rawURL[start:end], "/") {
+		if _, ok := pathParamName(segment); ok {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// ApplyPathParams replaces the path params of a URL with their values,
+// escaped for a path segment outside of their {{variables}}. The ones with
+// no value are left as written.
+func ApplyPathParams(rawURL string, values map[string]string) string {
+	start, end := pathBounds(rawURL)
+	segments := strings.Split(rawURL[start:end], "/")
+	for i, segment := range segments {
+		if name, ok := pathParamName(segment); ok && values[name] != "" {
+			segments[i] = encodePathSegment(values[name])
+		}
+	}
+	return rawURL[:start] + strings.Join(segments, "/") + rawURL[end:]
+}
+
+func encodePathSegment(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(s, -1) {
+		sb.WriteString(url.PathEscape(s[last:loc[0]]))
+		sb.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(url.PathEscape(s[last:]))
+	return sb.String()
+}
+
+// PathValues reads the values of the path params from the "path"
+// directives of a request
+func PathValues(directives []string) map[string]string {
+	values := map[string]string{}
+	for _, d := range directives {
+		name, rest, _ := strings.Cut(d, " ")
+		if name != pathDirective {
+			continue
+		}
+		key, value, _ := strings.Cut(rest, "=")
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// NewPathTable is the table of the path params of a request, its keys
+// come from the URL
+func NewPathTable() ParamsTable {
+	return ParamsTable{FixedKeys: true}
+}
+
+// SetPathParams fills the table with a row for each name, with its value
+func (t *ParamsTable) SetPathParams(names []string, values map[string]string) {
+	t.Rows = nil
+	for _, name := range names {
+		row := t.newRow(name, values[name])
+		row.KeyInput.Placeholder = ""
+		t.Rows = append(t.Rows, row)
+	}
+	t.sizeInputs()
+	t.FocusedRow, t.FocusedCol = 0, 1
+	t.Blur()
+}
+
+// PathDirectives returns the "path" directives of the rows with a value
+func (t *ParamsTable) PathDirectives() []string {
+	var directives []string
+	for _, row := range t.Rows {
+		if v := strings.TrimSpace(row.ValueInput.Value()); v != "" {
+			directives = append(directives, pathDirective+" "+row.KeyInput.Value()+"="+v)
+		}
+	}
+	return directives
+}
+
+// WithPathDirectives replaces the "path" directives of a request
+func WithPathDirectives(directives, paths []string) []string {
+	return replaceDirectives(directives, pathDirective, paths)
+}
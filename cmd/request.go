@@ -13,6 +13,44 @@ import (
 
 type request struct {
 	title, desc, method, endpoint, body, params, headers string
+	file                                                 string            // .http file the request belongs to in a workspace
+	depth                                                int               // indentation level in the workspace tree
+	marked                                               bool              // multi-selected in the requests list
+	dirty                                                bool              // edited since the last save
+	origin                                               string            // name in the file as last loaded or saved, empty for new requests
+	response                                             string            // example response for the mock server, kept as written
+	directives                                           []string          // "# @name value" comments of the request, kept as written
+	vars                                                 map[string]string // variables declared in the request
+}
+
+func requestFromHTTP(req HTTPRequest) request {
+	return request{
+		title:      req.Name,
+		desc:       req.Method,
+		method:     req.Method,
+		endpoint:   req.URL,
+		body:       req.Body,
+		params:     req.Params,
+		headers:    req.Headers,
+		origin:     req.Name,
+		response:   req.Response,
+		directives: req.Directives,
+		vars:       req.Vars,
+	}
+}
+
+func (r request) toHTTP() HTTPRequest {
+	return HTTPRequest{
+		Name:       r.Title(),
+		Method:     r.Method(),
+		URL:        r.Endpoint(),
+		Headers:    r.Headers(),
+		Body:       r.Body(),
+		Params:     r.Params(),
+		Response:   r.response,
+		Directives: r.directives,
+		Vars:       r.vars,
+	}
 }
 
 func (r request) Title() string       { return r.title }
@@ -24,7 +62,9 @@ func (r request) Params() string      { return r.params }
 func (r request) Headers() string     { return r.headers }
 func (r request) FilterValue() string { return r.title }
 
-type itemDelegate struct{}
+type itemDelegate struct {
+	rename string // rendered rename input shown in place of the selected title
+}
 
 func (d itemDelegate) Height() int                             { return 2 }
 func (d itemDelegate) Spacing() int                            { return 1 }
@@ -35,10 +75,22 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		title, desc string
 	)
 
-	if i, ok := listItem.(request); ok {
+	var indent string
+	switch i := listItem.(type) {
+	case request:
 		title = i.Title()
 		desc = i.Description()
-	} else {
+		indent = strings.Repeat("  ", i.depth)
+		if i.marked {
+			indent += "● "
+		}
+		if i.dirty {
+			title += " *"
+		}
+	case treeNode:
+		d.renderNode(w, m, index, i)
+		return
+	default:
 		return
 	}
 
@@ -73,6 +125,10 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		methodStyle = infoMethodStyle
 		methodInactiveStyle = infoMethodInactiveStyle
 		desc = " " + desc + " "
+	case "GRPC":
+		methodStyle = grpcMethodStyle
+		methodInactiveStyle = grpcMethodInactiveStyle
+		desc = " " + desc + " "
 	default:
 		methodStyle = otherMethodStyle
 		methodInactiveStyle = otherMethodInactiveStyle
@@ -81,7 +137,7 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 
 	// Prevent text from exceeding list width
 	textwidth := m.Width() - NormalTitleStyle.GetPaddingLeft() - NormalTitleStyle.GetPaddingRight()
-	title = ansi.Truncate(title, textwidth, "…")
+	title = ansi.Truncate(indent+title, textwidth, "…")
 	var lines []string
 	for i, line := range strings.Split(desc, "\n") {
 		if i >= d.Height()-1 {
@@ -95,7 +151,10 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	methodRender := methodStyle.Render(desc)
 	methodInactiveRender := methodInactiveStyle.Render(desc)
 
-	if index == m.Index() {
+	if index == m.Index() && d.rename != "" {
+		titleRender := selectedTitle.PaddingLeft(7 - len(desc)).Render("> " + indent + d.rename)
+		fmt.Fprintf(w, "%s%s\n", methodRender, titleRender)
+	} else if index == m.Index() {
 		titleRender := selectedTitle.PaddingLeft(7 - len(desc)).Render("> " + title)
 		fmt.Fprintf(w, "%s%s\n", methodRender, titleRender)
 	} else {
@@ -103,3 +162,24 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		fmt.Fprintf(w, "%s%s\n", methodInactiveRender, titleRender)
 	}
 }
+
+func (d itemDelegate) renderNode(w io.Writer, m list.Model, index int, node treeNode) {
+	if m.Width() <= 0 {
+		return
+	}
+	arrow := "▾ "
+	if node.collapsed {
+		arrow = "▸ "
+	}
+	name := node.name
+	if node.kind == folderNode {
+		name += "/"
+	}
+	textwidth := m.Width() - NormalTitleStyle.GetPaddingLeft() - NormalTitleStyle.GetPaddingRight()
+	text := ansi.Truncate(strings.Repeat("  ", node.depth)+arrow+name, textwidth, "…")
+	if index == m.Index() {
+		fmt.Fprintf(w, "%s\n", selectedTitle.Bold(true).Render(text))
+	} else {
+		fmt.Fprintf(w, "%s\n", treeNodeStyle.Render(text))
+	}
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TestResult is the outcome of one request of a collection run
+type TestResult struct {
+	Iteration int // 1-based row of the data file
+	Name      string
+	Method    string
+	URL       string
+	Status    int
+	Duration  time.Duration
+	Snapshot  string // SnapshotWritten, SnapshotMatched or SnapshotMissing when the response was checked against its snapshot
+	Err       error
+}
+
+func (r TestResult) Passed() bool {
+	return r.Err == nil && r.Status < 400
+}
+
+// CollectionItem is a request to run with the variables of its .http file
+type CollectionItem struct {
+	Request HTTPRequest
+	File    string
+}
+
+// RunnerOptions configures a collection run
+type RunnerOptions struct {
+	Rows          []map[string]string // one iteration per row feeding {{variables}}, nil for a single one
+	StopOnFailure bool
+	Delay         time.Duration // between two requests
+	Timeout       time.Duration // per request, 0 for none
+	// Snapshots compares the responses with their recorded snapshots,
+	// UpdateSnapshots records them again
+	Snapshots       bool
+	UpdateSnapshots bool
+	// Contracts validates the responses against the OpenAPI spec of their file
+	Contracts bool
+}
+
+// LoadDataRows reads the rows of a CSV file, its first line naming the
+// variables, or of a JSON file holding an array of objects
+func LoadDataRows(path string) ([]map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var objects []map[string]interface{}
+		if err := json.Unmarshal(content, &objects); err != nil {
+			return nil, fmt.Errorf("%s: expected an array of objects: %w", path, err)
+		}
+		rows := make([]map[string]string, len(objects))
+		for i, obj := range objects {
+			rows[i] = map[string]string{}
+			for k, v := range obj {
+				if s, ok := v.(string); ok {
+					rows[i][k] = s
+					continue
+				}
+				// Numbers, booleans and nested values keep their JSON form
+				b, _ := json.Marshal(v)
+				rows[i][k] = string(b)
+			}
+		}
+		return rows, nil
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	header := records[0]
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, name := range header {
+			if i < len(record) {
+				row[strings.TrimSpace(name)] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// RunCollection sends the items in order once per data row. The row
+// variables win over the ones of the requests, the files and the
+// environment, and are expanded in their values. An iteration leaving
+// {{variables}} undefined fails without being sent. onResult, when set,
+// is called after each request.
+func RunCollection(ctx context.Context, items []CollectionItem, envName string, opts RunnerOptions, onResult func(TestResult)) ([]TestResult, error) {
+	fileVars := map[string]map[string]string{}
+	specs := map[string]*OpenAPISpec{}
+	for _, item := range items {
+		if _, ok := fileVars[item.File]; ok {
+			continue
+		}
+		// As written, expanded with the row of each iteration
+		scope, err := ScopedVariables(item.File, envName)
+		if err != nil {
+			return nil, err
+		}
+		fileVars[item.File] = scopeValues(scope)
+		if opts.Contracts {
+			if path := FindOpenAPISpec(item.File, expandVariables(fileVars[item.File])); path != "" {
+				spec, err := LoadOpenAPI(path)
+				if err != nil {
+					return nil, err
+				}
+				specs[item.File] = spec
+			}
+		}
+	}
+	rows := opts.Rows
+	if rows == nil {
+		rows = []map[string]string{{}}
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	var results []TestResult
+	for i, row := range rows {
+		for j, item := range items {
+			if (i > 0 || j > 0) && opts.Delay > 0 {
+				select {
+				case <-time.After(opts.Delay):
+				case <-ctx.Done():
+					return results, ctx.Err()
+				}
+			}
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+
+			// The row wins over the variables of the request, and the file
+			// variables referencing its columns expand to its values
+			vars := maps.Clone(fileVars[item.File])
+			maps.Copy(vars, item.Request.Vars)
+			maps.Copy(vars, row)
+			vars = expandVariables(vars)
+			req := substituteVariables(item.Request, vars)
+			result := TestResult{Iteration: i + 1, Name: req.Name, Method: strings.ToUpper(req.Method), URL: req.URL}
+			// Literal braces sent to the server only show up as a 404
+			if undefined := UndefinedVariables(req, vars); len(undefined) > 0 {
+				result.Err = fmt.Errorf("undefined variables %s", strings.Join(undefined, ", "))
+			} else {
+				iteration := 0
+				if len(rows) > 1 {
+					iteration = i + 1
+				}
+				runItem(ctx, client, &result, item, req, vars, specs[item.File], iteration, opts)
+			}
+			results = append(results, result)
+			if onResult != nil {
+				onResult(result)
+			}
+			if opts.StopOnFailure && !result.Passed() {
+				return results, nil
+			}
+		}
+	}
+	return results, nil
+}
+
+// runItem sends the request of item, resolved as req, and checks the
+// response against the OpenAPI spec and the snapshot of the iteration
+func runItem(ctx context.Context, client *http.Client, result *TestResult, item CollectionItem, req HTTPRequest, vars map[string]string, spec *OpenAPISpec, iteration int, opts RunnerOptions) {
+	startTime := time.Now()
+	var header http.Header
+	var body string
+	result.Status, header, body, result.Err = doRequest(ctx, client, req, vars)
+	result.Duration = time.Since(startTime)
+	if spec != nil && result.Passed() && result.Method != "GRPC" {
+		if report := spec.CheckContract(result.Method, result.URL, result.Status, header, []byte(body)); !report.Passed() {
+			result.Err = ContractError{report}
+		}
+	}
+	if (opts.Snapshots || opts.UpdateSnapshots) && result.Passed() {
+		rules, _ := item.Request.Directive(snapshotIgnoreDirective)
+		migrateSnapshot(item.File, item.Request.Name, iteration)
+		path := SnapshotPath(item.File, item.Request.Name, iteration)
+		// Snapshots are committed, they must not hold secrets
+		result.Snapshot, result.Err = CheckSnapshot(path, MaskSecrets(body), ParseIgnoreRules(rules), opts.UpdateSnapshots)
+	}
+}
+
+// RunTests runs the named requests of a .http file, every request when
+// names is empty
+func RunTests(ctx context.Context, file string, names []string, envName string, opts RunnerOptions, onResult func(TestResult)) ([]TestResult, error) {
+	data, err := LoadHTTPFile(file)
+	if err != nil {
+		return nil, err
+	}
+	requests := data.Requests
+	if len(names) > 0 {
+		requests = nil
+		for _, name := range names {
+			req, err := FindRequest(data, name)
+			if err != nil {
+				return nil, err
+			}
+			requests = append(requests, req)
+		}
+	}
+	items := make([]CollectionItem, len(requests))
+	for i, req := range requests {
+		items[i] = CollectionItem{Request: req, File: file}
+	}
+	return RunCollection(ctx, items, envName, opts, onResult)
+}
+
+// doRequest sends a request, its variables already resolved, and returns
+// the status, headers and body of its response
+func doRequest(ctx context.Context, client *http.Client, req HTTPRequest, vars map[string]string) (int, http.Header, string, error) {
+	method := strings.ToUpper(req.Method)
+	if method == "GRPC" {
+		response, status, _ := sendGRPC(req.URL, req.Headers, req.Body, vars)
+		switch status {
+		case "OK":
+			return 0, nil, response, nil
+		case "":
+			return 0, nil, "", errors.New(strings.TrimSpace(response))
+		}
+		return 0, nil, "", fmt.Errorf("gRPC status %s", strings.TrimSpace(status))
+	}
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, req.URL, body)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	for _, line := range HeaderLines(req.Headers) {
+		key, value, err := ParseHeaderLine(line)
+		if err == nil {
+			httpReq.Header.Set(key, value)
+		}
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, "", err
+	}
+	return resp.StatusCode, resp.Header, string(b), nil
+}
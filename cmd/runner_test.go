@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCollectionRows(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+
+	file := filepath.Join(t.TempDir(), "api.http")
+	content := "@host = " + srv.URL + "\n@user = /users/{{id}}\n\n### user\nGET {{host}}{{user}}\n\n### orders\nGET {{host}}{{user}}/orders/{{order}}\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []map[string]string{{"id": "1"}, {"id": "2"}}
+	results, err := RunTests(context.Background(), file, nil, "", RunnerOptions{Rows: rows}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The file variable referencing the id column expands to the row
+	if want := "/users/1 /users/2"; strings.Join(paths, " ") != want {
+		t.Errorf("sent %q, want %q", paths, want)
+	}
+	if len(results) != 4 {
+		t.Fatalf("%d results, want 4", len(results))
+	}
+	for _, result := range results {
+		if result.Name == "orders" {
+			if result.Passed() || result.Err == nil || !strings.Contains(result.Err.Error(), "order") {
+				t.Errorf("iteration %d: err %v, want the undefined order", result.Iteration, result.Err)
+			}
+		} else if !result.Passed() {
+			t.Errorf("iteration %d: %v", result.Iteration, result.Err)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type runnerResultMsg struct {
+	id     int
+	result TestResult
+}
+
+type runnerDoneMsg struct {
+	id  int
+	err error
+}
+
+// runnerScreen runs the requests of a file, or the marked ones, in order
+type runnerScreen struct {
+	width         int
+	height        int
+	styles        *Styles
+	returnModel   Model
+	items         []CollectionItem
+	dataInput     textinput.Model
+	delayInput    textinput.Model
+	focus         int // 0 data file, 1 delay
+	stopOnFailure bool
+
+	id        int // drops the messages of a previous run
+	running   bool
+	cancel    context.CancelFunc
+	events    chan tea.Msg
+	results   []TestResult
+	iteration int
+	total     int // iterations of the run
+	started   time.Time
+	elapsed   time.Duration
+	err       error
+}
+
+// runnerItems picks the marked requests, or else every request of the
+// selected request's file
+func runnerItems(m Model) []CollectionItem {
+	var marked, all []CollectionItem
+	file := m.filepath
+	switch item := m.requestsList.SelectedItem().(type) {
+	case request:
+		if item.file != "" {
+			file = item.file
+		}
+	case treeNode:
+		if item.kind == fileNode {
+			file = item.path
+		}
+	}
+	for _, req := range allRequests(m.requestsList.Items()) {
+		reqFile := m.filepath
+		if req.file != "" {
+			reqFile = req.file
+		}
+		entry := CollectionItem{Request: req.toHTTP(), File: reqFile}
+		if req.marked {
+			marked = append(marked, entry)
+		}
+		if reqFile == file {
+			all = append(all, entry)
+		}
+	}
+	if len(marked) > 0 {
+		return marked
+	}
+	return all
+}
+
+func newRunnerScreen(m Model) runnerScreen {
+	s := runnerScreen{
+		width:       m.width,
+		height:      m.height,
+		styles:      m.styles,
+		returnModel: m,
+		items:       runnerItems(m),
+		dataInput:   textinput.New(),
+		delayInput:  textinput.New(),
+	}
+	s.dataInput.Prompt = "Data file: "
+	s.dataInput.Placeholder = "users.csv or users.json, empty for a single run"
+	s.dataInput.Width = 48
+	s.dataInput.Focus()
+	s.delayInput.Prompt = "Delay: "
+	s.delayInput.Placeholder = "0ms"
+	s.delayInput.Width = 10
+	return s
+}
+
+func (s runnerScreen) start() (runnerScreen, tea.Cmd) {
+	opts := RunnerOptions{StopOnFailure: s.stopOnFailure, Timeout: 30 * time.Second, Snapshots: true, Contracts: true}
+	if path := strings.TrimSpace(s.dataInput.Value()); path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(httpFilePath(s.returnModel.filepath)), path)
+		}
+		rows, err := LoadDataRows(path)
+		if err != nil {
+			s.err = err
+			return s, nil
+		}
+		opts.Rows = rows
+	}
+	if delay := strings.TrimSpace(s.delayInput.Value()); delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			s.err = fmt.Errorf("invalid delay %q, e.g. 500ms or 2s", delay)
+			return s, nil
+		}
+		opts.Delay = d
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.id++
+	s.running, s.cancel, s.err = true, cancel, nil
+	s.results, s.iteration, s.started, s.elapsed = nil, 0, time.Now(), 0
+	s.total = max(len(opts.Rows), 1)
+	s.events = make(chan tea.Msg)
+
+	id, events, items, envName := s.id, s.events, s.items, s.returnModel.environment
+	// Once cancelled nobody listens to the events anymore
+	send := func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		_, err := RunCollection(ctx, items, envName, opts, func(r TestResult) {
+			send(runnerResultMsg{id: id, result: r})
+		})
+		send(runnerDoneMsg{id: id, err: err})
+	}()
+	return s, waitRunner(events)
+}
+
+func waitRunner(events chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+func (s runnerScreen) stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+func (s runnerScreen) Init() tea.Cmd {
+	return nil
+}
+
+func (s runnerScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		return s, nil
+	case runnerResultMsg:
+		if msg.id != s.id {
+			return s, nil
+		}
+		s.results = append(s.results, msg.result)
+		s.iteration = msg.result.Iteration
+		return s, waitRunner(s.events)
+	case runnerDoneMsg:
+		if msg.id != s.id {
+			return s, nil
+		}
+		s.stop()
+		s.running = false
+		s.elapsed = time.Since(s.started)
+		if msg.err != nil && msg.err != context.Canceled {
+			s.err = msg.err
+		}
+		return s, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			s.stop()
+			return s.returnModel.confirmQuit()
+		case "esc":
+			s.stop()
+			s.returnModel.width = s.width
+			s.returnModel.height = s.height
+			return s.returnModel, s.returnModel.restartWatch()
+		case "enter":
+			if s.running || len(s.items) == 0 {
+				return s, nil
+			}
+			return s.start()
+		case "ctrl+t":
+			s.stopOnFailure = !s.stopOnFailure
+			return s, nil
+		case "tab", "shift+tab":
+			s.focus = 1 - s.focus
+			if s.focus == 0 {
+				s.delayInput.Blur()
+				s.dataInput.Focus()
+			} else {
+				s.dataInput.Blur()
+				s.delayInput.Focus()
+			}
+			return s, nil
+		}
+	}
+	if s.focus == 0 {
+		s.dataInput, cmd = s.dataInput.Update(msg)
+	} else {
+		s.delayInput, cmd = s.delayInput.Update(msg)
+	}
+	return s, cmd
+}
+
+func (s runnerScreen) View() string {
+	header := s.appTopLabel(fmt.Sprintf("POSTBEAR Runner  %d requests", len(s.items)))
+
+	var b strings.Builder
+	check := "[ ]"
+	if s.stopOnFailure {
+		check = "[x]"
+	}
+	b.WriteString(s.dataInput.View() + "   " + s.delayInput.View() + "   " + check + " Stop on failure (ctrl+t)\n\n")
+
+	switch {
+	case len(s.items) == 0:
+		b.WriteString(NormalTitleStyle.Render("No requests to run"))
+	case s.results == nil && !s.running && s.err == nil:
+		for _, item := range s.items {
+			b.WriteString(fmt.Sprintf("  %-7s %s\n", strings.ToUpper(item.Request.Method), item.Request.Name))
+		}
+	default:
+		passed := 0
+		for _, r := range s.results {
+			if r.Passed() {
+				passed++
+			}
+		}
+		state := fmt.Sprintf("Running iteration %d/%d...", max(s.iteration, 1), s.total)
+		if !s.running {
+			state = fmt.Sprintf("Done in %.2fs", s.elapsed.Seconds())
+		}
+		b.WriteString(boldStyle.Render(state) + fmt.Sprintf("  %d passed, %d failed\n\n", passed, len(s.results)-passed))
+
+		// Keep the latest results in view
+		rows := max(s.height-12, 1)
+		start := max(len(s.results)-rows, 0)
+		for _, r := range s.results[start:] {
+			mark := codes200Style.Render(" ✓ ")
+			detail := statusCodeStyle(fmt.Sprint(r.Status)).Render(fmt.Sprintf(" %d ", r.Status))
+			if !r.Passed() {
+				mark = codes500Style.Render(" ✗ ")
+			}
+			if r.Err != nil {
+				detail = MaskSecrets(r.Err.Error())
+			}
+			line := fmt.Sprintf("%s #%-3d %-7s %-24s %s %s", mark, r.Iteration, r.Method, r.Name, detail, responseTimeStyle.Render(fmt.Sprintf(" %vms ", r.Duration.Milliseconds())))
+			b.WriteString(ansi.Truncate(line, s.width-6, "…") + "\n")
+		}
+	}
+	if s.err != nil {
+		b.WriteString("\n" + codes500Style.Render(" "+s.err.Error()+" "))
+	}
+
+	body := borderStyle.Width(s.width - 2).Height(s.height - 4).Render(lipgloss.NewStyle().Padding(0, 1).Render(b.String()))
+	footer := s.appBottomLabel("enter to run, tab to switch fields, <ESC> to stop and go back")
+	return s.styles.Base.Render(header + "\n" + body + "\n" + footer)
+}
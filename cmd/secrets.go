@@ -0,0 +1,439 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// secretPrefix reads a value of the secret store, as in {{$secret apiToken}}.
+// The .http file only holds the name of the secret.
+const secretPrefix = "$secret "
+
+// secretMask replaces the values of secrets in what is shown or saved
+const secretMask = "******"
+
+// minMaskedLength is the length of the shortest value masked, shorter ones
+// like "1" or "true" would mask unrelated text of the responses
+const minMaskedLength = 6
+
+const (
+	secretService       = "postbear"
+	secretStoreEnv      = "POSTBEAR_SECRET_STORE"
+	secretPassphraseEnv = "POSTBEAR_SECRET_PASSPHRASE"
+	keyIterations       = 600000
+)
+
+// ErrSecretNotFound is returned by the stores for a name they don't hold
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ErrSecretsLocked is returned by the file store while it has no passphrase
+var ErrSecretsLocked = fmt.Errorf("no OS keyring found, set %s or enter the passphrase of the secrets file (alt+u in the TUI)", secretPassphraseEnv)
+
+// PassphrasePrompt asks for the passphrase of the secrets file when
+// POSTBEAR_SECRET_PASSPHRASE is not set, nil when there is no one to ask
+var PassphrasePrompt func() (string, error)
+
+// secretPassphrase is the passphrase entered during this run, locked tells
+// a lookup found the file store without one
+var secretPassphrase struct {
+	sync.Mutex
+	value  string
+	locked bool
+}
+
+// SecretStore keeps the values of secrets outside of the .http files
+type SecretStore interface {
+	Name() string
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// SecretReference is the value a variable holds when its secret is stored
+// under name
+func SecretReference(name string) string {
+	return "{{" + secretPrefix + name + "}}"
+}
+
+// OpenSecretStore picks the OS keyring when there is one, the encrypted file
+// otherwise. POSTBEAR_SECRET_STORE=keyring or file forces one of them.
+func OpenSecretStore() (SecretStore, error) {
+	switch kind := strings.ToLower(os.Getenv(secretStoreEnv)); kind {
+	case "":
+		if store, ok := findKeyring(); ok {
+			return store, nil
+		}
+		return newFileStore()
+	case "keyring":
+		store, ok := findKeyring()
+		if !ok {
+			return nil, fmt.Errorf("no OS keyring found, install secret-tool or set %s=file", secretStoreEnv)
+		}
+		return store, nil
+	case "file":
+		return newFileStore()
+	default:
+		return nil, fmt.Errorf("unknown secret store %q, expected keyring or file", kind)
+	}
+}
+
+// keyringStore goes through the command line tool of the OS keyring:
+// secret-tool for the Secret Service on Linux, security on macOS
+type keyringStore struct {
+	tool string
+}
+
+func findKeyring() (keyringStore, bool) {
+	tool := "secret-tool"
+	if runtime.GOOS == "darwin" {
+		tool = "security"
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return keyringStore{}, false
+	}
+	return keyringStore{tool: tool}, true
+}
+
+func (k keyringStore) Name() string {
+	return "keyring"
+}
+
+func (k keyringStore) Get(name string) (string, error) {
+	args := []string{"lookup", "service", secretService, "name", name}
+	if k.tool == "security" {
+		args = []string{"find-generic-password", "-s", secretService, "-a", name, "-w"}
+	}
+	out, err := exec.Command(k.tool, args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) || (err == nil && len(out) == 0) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (k keyringStore) Set(name, value string) error {
+	c := exec.Command(k.tool, "store", "--label", secretService+" "+name, "service", secretService, "name", name)
+	c.Stdin = strings.NewReader(value)
+	if k.tool == "security" {
+		// security -i reads the command on stdin, so the value is not in
+		// the arguments other users can list. -X takes it in hex.
+		c = exec.Command(k.tool, "-i")
+		c.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+			shellQuote(secretService), shellQuote(name), hex.EncodeToString([]byte(value))))
+	}
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("storing %s in the keyring: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k keyringStore) Delete(name string) error {
+	args := []string{"clear", "service", secretService, "name", name}
+	if k.tool == "security" {
+		args = []string{"delete-generic-password", "-s", secretService, "-a", name}
+	}
+	if out, err := exec.Command(k.tool, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("removing %s from the keyring: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// fileStore keeps the secrets in a file of the user config directory,
+// encrypted with AES-GCM and a key derived from POSTBEAR_SECRET_PASSPHRASE,
+// or the passphrase entered during this run
+type fileStore struct {
+	path       string
+	passphrase string
+}
+
+// sealedSecrets is the content of the encrypted file
+type sealedSecrets struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+func newFileStore() (fileStore, error) {
+	passphrase := os.Getenv(secretPassphraseEnv)
+	if passphrase == "" {
+		var err error
+		if passphrase, err = enteredPassphrase(); err != nil {
+			return fileStore{}, err
+		}
+	}
+	return openFileStore(passphrase)
+}
+
+func openFileStore(passphrase string) (fileStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return fileStore{}, err
+	}
+	return fileStore{path: filepath.Join(dir, "postbear", "secrets.json"), passphrase: passphrase}, nil
+}
+
+// enteredPassphrase is the passphrase entered during this run, asked with
+// PassphrasePrompt the first time
+func enteredPassphrase() (string, error) {
+	secretPassphrase.Lock()
+	defer secretPassphrase.Unlock()
+	if secretPassphrase.value == "" && PassphrasePrompt != nil {
+		value, err := PassphrasePrompt()
+		if err != nil {
+			return "", err
+		}
+		secretPassphrase.value = value
+	}
+	if secretPassphrase.value == "" {
+		secretPassphrase.locked = true
+		return "", ErrSecretsLocked
+	}
+	return secretPassphrase.value, nil
+}
+
+// UnlockSecrets sets the passphrase of the secrets file for this run, it is
+// checked against the file when there is one
+func UnlockSecrets(passphrase string) error {
+	if passphrase == "" {
+		return errors.New("the passphrase is empty")
+	}
+	f, err := openFileStore(passphrase)
+	if err != nil {
+		return err
+	}
+	if _, err := f.load(); err != nil {
+		return err
+	}
+	secretPassphrase.Lock()
+	secretPassphrase.value = passphrase
+	secretPassphrase.locked = false
+	secretPassphrase.Unlock()
+	return nil
+}
+
+// secretsLocked reports whether a secret was looked up in the file store
+// before its passphrase was entered
+func secretsLocked() bool {
+	secretPassphrase.Lock()
+	defer secretPassphrase.Unlock()
+	return secretPassphrase.locked
+}
+
+func (f fileStore) Name() string {
+	return f.path
+}
+
+func (f fileStore) Get(name string) (string, error) {
+	values, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := values[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (f fileStore) Set(name, value string) error {
+	values, err := f.load()
+	if err != nil {
+		return err
+	}
+	values[name] = value
+	return f.save(values)
+}
+
+func (f fileStore) Delete(name string) error {
+	values, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := values[name]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(values, name)
+	return f.save(values)
+}
+
+func (f fileStore) load() (map[string]string, error) {
+	values := map[string]string{}
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sealed sealedSecrets
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	gcm, err := newGCM(deriveKey(f.passphrase, sealed.Salt))
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, sealed.Nonce, sealed.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase for %s", f.path)
+	}
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	return values, nil
+}
+
+func (f fileStore) save(values map[string]string) error {
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	// A fresh salt and nonce on every write
+	sealed := sealedSecrets{Salt: make([]byte, 16)}
+	if _, err := rand.Read(sealed.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(deriveKey(f.passphrase, sealed.Salt))
+	if err != nil {
+		return err
+	}
+	sealed.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(sealed.Nonce); err != nil {
+		return err
+	}
+	sealed.Data = gcm.Seal(nil, sealed.Nonce, plain, nil)
+	raw, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, raw, 0600)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveKey is PBKDF2 with HMAC-SHA256, for a 32 bytes key
+func deriveKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, keyIterations, 32, sha256.New)
+}
+
+// secretCache holds the secrets read during this run, so the store is asked
+// once per name, and so their values can be masked. Missing ones are nil.
+var secretCache = struct {
+	sync.Mutex
+	values map[string]*string
+}{values: map[string]*string{}}
+
+// lookupSecret reads a secret from the store, a missing one is undefined
+func lookupSecret(name string) (string, bool) {
+	secretCache.Lock()
+	defer secretCache.Unlock()
+	if value, ok := secretCache.values[name]; ok {
+		if value == nil {
+			return "", false
+		}
+		return *value, true
+	}
+	store, err := OpenSecretStore()
+	if errors.Is(err, ErrSecretsLocked) {
+		// Looked up again once the passphrase is entered
+		return "", false
+	}
+	secretCache.values[name] = nil
+	if err != nil {
+		return "", false
+	}
+	value, err := store.Get(name)
+	if err != nil {
+		return "", false
+	}
+	secretCache.values[name] = &value
+	return value, true
+}
+
+// SetSecret stores the value of a secret, and returns the store used
+func SetSecret(name, value string) (SecretStore, error) {
+	store, err := OpenSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Set(name, value); err != nil {
+		return nil, err
+	}
+	secretCache.Lock()
+	secretCache.values[name] = &value
+	secretCache.Unlock()
+	return store, nil
+}
+
+// DeleteSecret removes a secret from the store
+func DeleteSecret(name string) error {
+	store, err := OpenSecretStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(name); err != nil {
+		return err
+	}
+	secretCache.Lock()
+	secretCache.values[name] = nil
+	secretCache.Unlock()
+	return nil
+}
+
+// SetSecretVar stores value as a secret and sets the global variable name
+// of a .http file to a reference to it
+func SetSecretVar(file, name, value string) (SecretStore, error) {
+	store, err := SetSecret(name, value)
+	if err != nil {
+		return nil, err
+	}
+	ref := SecretReference(name)
+	return store, SetGlobalVar(file, name, &ref)
+}
+
+// MaskSecrets hides the values of the secrets read so far in text, the
+// ones shorter than minMaskedLength are left alone
+func MaskSecrets(text string) string {
+	secretCache.Lock()
+	var values []string
+	for _, value := range secretCache.values {
+		if value != nil && len(*value) >= minMaskedLength {
+			values = append(values, *value)
+		}
+	}
+	secretCache.Unlock()
+	// Longer values first, so one holding another is masked whole
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	for _, value := range values {
+		text = strings.ReplaceAll(text, value, secretMask)
+	}
+	return text
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import "testing"
+
+func TestMaskSecrets(t *testing.T) {
+	secretCache.Lock()
+	saved := secretCache.values
+	short, token, longer := "true", "s3cr3t-token", "s3cr3t-token-2"
+	secretCache.values = map[string]*string{"short": &short, "token": &token, "longer": &longer, "missing": nil}
+	secretCache.Unlock()
+	t.Cleanup(func() {
+		secretCache.Lock()
+		secretCache.values = saved
+		secretCache.Unlock()
+	})
+
+	got := MaskSecrets(`{"ok": true, "a": "s3cr3t-token", "b": "s3cr3t-token-2"}`)
+	if want := `{"ok": true, "a": "******", "b": "******"}`; got != want {
+		t.Errorf("MaskSecrets = %q, want %q", got, want)
+	}
+}
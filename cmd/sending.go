@@ -5,20 +5,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/TylerBrock/colorjson"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
 )
 
-func sendByTUI(m Model) (string, string, string) {
-	variables := LoadGlobalVarsFromHTTPFile(m.filepath)
+// sendByTUI sends the request in the fields with the variables of envName,
+// returning the body, the status, the response time and the headers
+func sendByTUI(m Model, envName string) (string, string, string, http.Header) {
+	variables := m.variablesFor(envName)
 	method := strings.ToUpper(strings.TrimSpace(m.methodField.Value()))
-	URL := strings.TrimSpace(m.urlField.Value())
+	URL := ApplyPathParams(strings.TrimSpace(m.urlField.Value()), PathValues(m.editedRequest().Directives))
 	headersJSON := strings.TrimSpace(m.headersArea.Value())
 	// paramsJSON := strings.TrimSpace(m.tabContent[paramsTab].Value())
 
@@ -37,11 +41,19 @@ func sendByTUI(m Model) (string, string, string) {
 	headersJSON = replacePlaceholders(headersJSON, variables)
 	// paramsJSON = replacePlaceholders(paramsJSON, variables)
 
+	if method == "GRPC" {
+		body, status, responseTime := sendGRPC(URL, headersJSON, replacePlaceholders(m.bodyArea.Value(), variables), variables)
+		return body, status, responseTime, nil
+	}
+
 	// Parse JSON into a map
 	var headers map[string]string
 	err := json.Unmarshal([]byte(headersJSON), &headers)
 	if err != nil {
-		return " \n Error parsing Headers \n\n Correct the Headers format", " Incorrect Headers ", ""
+		if bodyErr := ValidateHeaders(m.headersArea.Value()); bodyErr != nil {
+			return " \n Error parsing Headers \n\n " + bodyErr.Error(), " Incorrect Headers ", "", nil
+		}
+		return " \n Error parsing Headers \n\n Correct the Headers format", " Incorrect Headers ", "", nil
 	}
 
 	// if paramsJSON != "" {
@@ -80,7 +92,7 @@ func sendByTUI(m Model) (string, string, string) {
 	client := &http.Client{}
 	req, err := http.NewRequest(method, URL, payload)
 	if err != nil {
-		return "Failed to make request\n\n" + err.Error(), "", ""
+		return "Failed to make request\n\n" + err.Error(), "", "", nil
 	}
 
 	// Set headers
@@ -93,7 +105,7 @@ func sendByTUI(m Model) (string, string, string) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "Failed to make request\n\n" + err.Error(), "", ""
+		return "Failed to make request\n\n" + err.Error(), "", "", nil
 	}
 	defer resp.Body.Close()
 
@@ -103,56 +115,173 @@ func sendByTUI(m Model) (string, string, string) {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "Failed to read response body\n\n" + err.Error(), "", ""
+		return "Failed to read response body\n\n" + err.Error(), "", "", nil
+	}
+	return string(body), fmt.Sprint(resp.StatusCode), fmt.Sprintf(" %vms ", ms), resp.Header
+}
+
+// Output formats of the CLI
+const (
+	OutputPretty = "pretty" // styled text for people
+	OutputJSON   = "json"   // a cliEnvelope document
+	OutputRaw    = "raw"    // the response body bytes, untouched
+)
+
+// NetworkError reports a request that got no response at all
+type NetworkError struct {
+	Err error
+}
+
+func (e NetworkError) Error() string { return e.Err.Error() }
+func (e NetworkError) Unwrap() error { return e.Err }
+
+// InvalidRequestError reports a request that cannot be sent as written,
+// like a gRPC body or headers that do not parse
+type InvalidRequestError struct {
+	Err error
+}
+
+func (e InvalidRequestError) Error() string { return e.Err.Error() }
+func (e InvalidRequestError) Unwrap() error { return e.Err }
+
+// StatusError reports a 4xx/5xx response when the CLI runs with --fail
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e StatusError) Error() string { return "server answered " + e.Status }
+
+// noColor turns off the colors of the CLI output
+var noColor bool
+
+// ColorEnabled reports whether the CLI output should be colored: stdout is
+// a terminal and NO_COLOR is not set
+func ColorEnabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// DisableColor makes the CLI print plain text
+func DisableColor() {
+	noColor = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// CLIOptions configures a request sent from the command line
+type CLIOptions struct {
+	Headers []string // "Name: value" lines, later ones win
+	Body    string
+	Timeout time.Duration
+	Verbose bool   // print the request to stderr before sending it
+	Output  string // OutputPretty, OutputJSON or OutputRaw
+	Simple  bool   // print only the response body
+	Fail    bool   // return a StatusError on 4xx/5xx responses
+
+	envelopes *[]cliEnvelope // collects the OutputJSON documents instead of printing them
+}
+
+// cliEnvelope is the --output json document describing a request and its response
+type cliEnvelope struct {
+	Request struct {
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body,omitempty"`
+	} `json:"request"`
+	Status     int               `json:"status"`
+	StatusText string            `json:"statusText"`
+	Protocol   string            `json:"protocol"`
+	Headers    map[string]string `json:"headers"`
+	Timings    struct {
+		TotalMs int64 `json:"totalMs"`
+	} `json:"timings"`
+	Body json.RawMessage `json:"body"`
+}
+
+// ParseHeaderLine splits a "Name: value" header line
+func ParseHeaderLine(line string) (string, string, error) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected 'Name: value'", line)
 	}
-	return string(body), fmt.Sprint(resp.StatusCode), fmt.Sprintf(" %vms ", ms)
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
 }
 
-func SendByCLI(method string, url string, simpleOutput bool, payloadIdx int) {
+func SendByCLI(method string, url string, opts CLIOptions) error {
+	// Any method can carry a body, DELETE and custom verbs included
 	var reqBody io.Reader
-	if (method == "POST" || method == "PUT" || method == "PATCH") && len(os.Args) > payloadIdx {
-		payload := os.Args[payloadIdx]
-		reqBody = bytes.NewBuffer([]byte(payload))
+	if opts.Body != "" {
+		reqBody = bytes.NewBuffer([]byte(opts.Body))
 	}
 
 	req, err := http.NewRequest(method, url, reqBody)
 	if err != nil {
-		log.Fatalf("Error creating request: %v", err)
+		return fmt.Errorf("creating request: %w", err)
 	}
 
-	if method == "POST" || method == "PUT" || method == "PATCH" {
+	// A JSON body is sent as such unless a -H Content-Type, set below, says otherwise
+	if opts.Body != "" && json.Valid([]byte(opts.Body)) {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	req.Header.Set("User-Agent", "my-simple-go-client/1.0")
+	for _, line := range opts.Headers {
+		key, value, err := ParseHeaderLine(line)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(key, value)
+	}
 
-	client := &http.Client{}
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "> %s %s\n", method, MaskSecrets(url))
+		for key, values := range req.Header {
+			fmt.Fprintf(os.Stderr, "> %s: %s\n", key, MaskSecrets(strings.Join(values, ", ")))
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
 	startTime := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatalf("Error making request: %v", err)
+		return NetworkError{fmt.Errorf("making request: %w", err)}
 	}
 	defer resp.Body.Close()
 	duration := time.Since(startTime)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Fatalf("Error reading response body: %v", err)
+		return NetworkError{fmt.Errorf("reading response body: %w", err)}
 	}
 
-	if simpleOutput {
-		printResesponseBody(body)
-		return
+	// The raw output is left untouched for pipes, the others hide secrets
+	shown := []byte(MaskSecrets(string(body)))
+	switch {
+	case opts.Output == OutputJSON:
+		err = printEnvelope(req, opts.Body, resp, shown, duration, opts.envelopes)
+	case opts.Output == OutputRaw:
+		_, err = os.Stdout.Write(body)
+	case opts.Simple:
+		printResesponseBody(shown)
+	default:
+		printResponse(method, MaskSecrets(url), resp, shown, duration)
+	}
+	if err != nil {
+		return err
 	}
+	if opts.Fail && resp.StatusCode >= 400 {
+		return StatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
 
-	statusStyle := codes200Style
+func printResponse(method, url string, resp *http.Response, body []byte, duration time.Duration) {
 
-	if resp.StatusCode >= 500 {
-		statusStyle = codes500Style
-	} else if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-		statusStyle = codes400Style
-	} else if resp.StatusCode >= 300 && resp.StatusCode < 400 {
-		statusStyle = codes300Style
-	}
+	statusStyle := statusCodeStyle(fmt.Sprint(resp.StatusCode))
 
 	// --- Print all available response information using lipgloss ---
 	urlStyle := boldStyle.Foreground(lipgloss.Color("5")).Background(lipgloss.Color("17")).Padding(0, 1)
@@ -174,6 +303,8 @@ func SendByCLI(method string, url string, simpleOutput bool, payloadIdx int) {
 		methodStyle = deleteMethodStyle
 	case "INFO":
 		methodStyle = infoMethodStyle
+	case "GRPC":
+		methodStyle = grpcMethodStyle
 	}
 
 	fmt.Println(methodStyle.Render(method) + urlStyle.Render(url))
@@ -184,19 +315,115 @@ func SendByCLI(method string, url string, simpleOutput bool, payloadIdx int) {
 	fmt.Println(labelStyle.Render("Response Time:") + " " + valueStyle.Render(fmt.Sprintf("%vms", duration.Milliseconds())))
 	fmt.Println(headerStyle.Render("Headers:"))
 	for key, values := range resp.Header {
-		fmt.Println(labelStyle.Render("  "+key+":") + " " + valueStyle.Render(strings.Join(values, ", ")))
+		fmt.Println(labelStyle.Render("  "+key+":") + " " + valueStyle.Render(MaskSecrets(strings.Join(values, ", "))))
 	}
 	// --- Print the final endpoint result (response body) with colors ---
 	fmt.Println(headerStyle.Render("Response:"))
 	printResesponseBody(body)
+}
 
+func printEnvelope(req *http.Request, reqBody string, resp *http.Response, body []byte, duration time.Duration, collected *[]cliEnvelope) error {
+	var env cliEnvelope
+	env.Request.Method = req.Method
+	env.Request.URL = MaskSecrets(req.URL.String())
+	env.Request.Headers = maskHeader(flattenHeader(req.Header))
+	env.Request.Body = MaskSecrets(reqBody)
+	env.Status = resp.StatusCode
+	env.StatusText = resp.Status
+	env.Protocol = resp.Proto
+	env.Headers = maskHeader(flattenHeader(resp.Header))
+	env.Timings.TotalMs = duration.Milliseconds()
+	return writeEnvelope(env, body, collected)
+}
+
+// printGRPCEnvelope prints the cliEnvelope of a gRPC call, its status is
+// the number of the gRPC code and its protocol "gRPC"
+func printGRPCEnvelope(req HTTPRequest, body, code string, duration time.Duration, collected *[]cliEnvelope) error {
+	var env cliEnvelope
+	env.Request.Method = "GRPC"
+	env.Request.URL = MaskSecrets(req.URL)
+	env.Request.Headers = map[string]string{}
+	_ = json.Unmarshal([]byte(req.Headers), &env.Request.Headers)
+	env.Request.Headers = maskHeader(env.Request.Headers)
+	env.Request.Body = MaskSecrets(req.Body)
+	env.Status = int(grpcCode(code))
+	env.StatusText = strings.TrimSpace(code)
+	env.Protocol = "gRPC"
+	env.Headers = map[string]string{}
+	env.Timings.TotalMs = duration.Milliseconds()
+	return writeEnvelope(env, []byte(body), collected)
+}
+
+// writeEnvelope prints env with its body, or appends it to collected when
+// set
+func writeEnvelope(env cliEnvelope, body []byte, collected *[]cliEnvelope) error {
+	// JSON bodies are embedded as they are, anything else as a string
+	if json.Valid(body) {
+		env.Body = body
+	} else {
+		env.Body, _ = json.Marshal(string(body))
+	}
+	if collected != nil {
+		*collected = append(*collected, env)
+		return nil
+	}
+	return printJSON(env)
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func flattenHeader(header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for key, values := range header {
+		flat[key] = strings.Join(values, ", ")
+	}
+	return flat
+}
+
+// maskHeader hides the secrets in the values of a flattened header
+func maskHeader(header map[string]string) map[string]string {
+	for key, value := range header {
+		header[key] = MaskSecrets(value)
+	}
+	return header
 }
 
 func printResesponseBody(body []byte) {
-	var obj map[string]interface{}
-	json.Unmarshal([]byte(body), &obj)
+	// Bodies that aren't JSON are printed as they are
+	var obj interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		fmt.Println(string(body))
+		return
+	}
 	fb := colorjson.NewFormatter()
 	fb.Indent = 2
+	fb.DisabledColor = noColor
 	s, _ := fb.Marshal(obj)
 	fmt.Println(string(s))
 }
+
+// statusCodeStyle picks the badge style for an HTTP status code or a gRPC status name
+func statusCodeStyle(statusCode string) lipgloss.Style {
+	code, err := strconv.Atoi(statusCode)
+	if err != nil {
+		if statusCode == "" || statusCode == "OK" {
+			return codes200Style
+		}
+		return codes500Style
+	}
+	if code >= 500 {
+		return codes500Style
+	} else if code >= 400 && code < 500 {
+		return codes400Style
+	} else if code >= 300 && code < 400 {
+		return codes300Style
+	}
+	return codes200Style
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Separators of the HTTPie style request items, longest first so that
+// "a:=1" is a raw JSON field and not a header
+var shorthandSeparators = []string{":=", "==", "=", ":"}
+
+// Shorthand is what a list of HTTPie style request items builds
+type Shorthand struct {
+	Headers []string   // "Name: value" lines from Name:value items
+	Query   url.Values // from name==value items
+	Fields  map[string]json.RawMessage
+}
+
+// Body returns the JSON object built from the fields, "" when there are none
+func (s Shorthand) Body() string {
+	if len(s.Fields) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(s.Fields)
+	return string(b)
+}
+
+// ParseShorthand reads HTTPie style request items:
+//
+//	name=value   string field of the JSON body
+//	name:=json   raw JSON field of the JSON body
+//	name==value  query parameter
+//	Name:value   header
+//
+// The first separator of an item decides its kind.
+func ParseShorthand(items []string) (Shorthand, error) {
+	s := Shorthand{Query: url.Values{}, Fields: map[string]json.RawMessage{}}
+	for _, item := range items {
+		key, sep, value := splitShorthand(item)
+		if key == "" {
+			return s, fmt.Errorf("invalid request item %q, expected name=value, name:=json, name==value or Header:value", item)
+		}
+		switch sep {
+		case ":=":
+			if !json.Valid([]byte(value)) {
+				return s, fmt.Errorf("invalid JSON in %q", item)
+			}
+			s.Fields[key] = json.RawMessage(value)
+		case "==":
+			s.Query.Add(key, value)
+		case "=":
+			b, _ := json.Marshal(value)
+			s.Fields[key] = b
+		case ":":
+			if strings.ContainsAny(key, " \t\"'{}[]()") {
+				return s, fmt.Errorf("invalid header name in %q", item)
+			}
+			s.Headers = append(s.Headers, key+": "+value)
+		}
+	}
+	return s, nil
+}
+
+func splitShorthand(item string) (string, string, string) {
+	for i := range item {
+		for _, sep := range shorthandSeparators {
+			if strings.HasPrefix(item[i:], sep) {
+				return strings.TrimSpace(item[:i]), sep, item[i+len(sep):]
+			}
+		}
+	}
+	return "", "", ""
+}
+
+// AddQuery appends query parameters to rawURL
+func AddQuery(rawURL string, query url.Values) (string, error) {
+	if len(query) == 0 {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for key, values := range query {
+		for _, v := range values {
+			q.Add(key, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// snapshotDir holds the recorded responses, next to the .http file
+const snapshotDir = "__snapshots__"
+
+// snapshotIgnoreDirective lists the JSON paths left out of the snapshot of
+// a request, e.g. "# @snapshotIgnore id, createdAt, items.*.id"
+const snapshotIgnoreDirective = "snapshotIgnore"
+
+// ignoredValue replaces the ignored fields in a snapshot
+const ignoredValue = "<ignored>"
+
+// Snapshot states of a TestResult
+const (
+	SnapshotWritten = "written"
+	SnapshotMatched = "matched"
+	SnapshotMissing = "missing"
+)
+
+// SnapshotError is the failure of a response that no longer matches its snapshot
+type SnapshotError struct {
+	Path  string
+	Lines []string // differences from the snapshot to the response, "+ " / "- " / "~ " prefixed
+}
+
+func (e SnapshotError) Error() string {
+	return fmt.Sprintf("response differs from the snapshot %s", e.Path)
+}
+
+// String renders the differences for people
+func (e SnapshotError) String() string {
+	var sb strings.Builder
+	for _, line := range e.Lines {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			line = diffAddedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = diffRemovedStyle.Render(line)
+		case strings.HasPrefix(line, "~"):
+			line = diffChangedStyle.Render(line)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// SnapshotPath is the snapshot file of a request of a .http file,
+// iteration being 0 for a run without data rows. The slug of the name is
+// followed by a hash of the exact name, "Get user" and "get-user" have a
+// snapshot each.
+func SnapshotPath(file, name string, iteration int) string {
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	snap := fmt.Sprintf("%s-%08x", fileSlug(name), h.Sum32())
+	if iteration > 0 {
+		snap += "." + strconv.Itoa(iteration)
+	}
+	return filepath.Join(filepath.Dir(httpFilePath(file)), snapshotDir, base, snap+".snap")
+}
+
+// migrateSnapshot renames the snapshot of a request recorded before the
+// hash of its name was part of the path
+func migrateSnapshot(file, name string, iteration int) {
+	path := SnapshotPath(file, name, iteration)
+	legacy := fileSlug(name)
+	if iteration > 0 {
+		legacy += "." + strconv.Itoa(iteration)
+	}
+	legacy = filepath.Join(filepath.Dir(path), legacy+".snap")
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		os.Rename(legacy, path)
+	}
+}
+
+// fileSlug turns a name into a file name
+func fileSlug(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	if s := strings.TrimSuffix(sb.String(), "-"); s != "" {
+		return s
+	}
+	return "request"
+}
+
+// NormalizeSnapshot turns a response body into its snapshot: JSON is
+// indented with sorted keys and its ignored fields replaced, other bodies
+// keep their text with unix line endings
+func NormalizeSnapshot(body string, rules []string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err == nil {
+		var sb strings.Builder
+		enc := json.NewEncoder(&sb)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(maskIgnored("", v, rules))
+		return sb.String()
+	}
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+func maskIgnored(at string, v interface{}, rules []string) interface{} {
+	if at != "" && ignored(at, rules) {
+		return ignoredValue
+	}
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, field := range value {
+			value[k] = maskIgnored(joinPath(at, k), field, rules)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = maskIgnored(joinPath(at, strconv.Itoa(i)), item, rules)
+		}
+	}
+	return v
+}
+
+// CheckSnapshot compares a response body with the snapshot at path. The
+// snapshot is written when update is set. A missing one fails with the
+// SnapshotMissing state when the .http file has snapshots, and is not
+// checked, with an empty state, when it has none.
+func CheckSnapshot(path, body string, rules []string, update bool) (string, error) {
+	actual := NormalizeSnapshot(body, rules)
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			return "", err
+		}
+		return SnapshotWritten, nil
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			return "", nil
+		}
+		return SnapshotMissing, fmt.Errorf("no snapshot %s, record it with --update-snapshots", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	recorded := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if recorded == actual {
+		return SnapshotMatched, nil
+	}
+
+	var lines []string
+	var a, b interface{}
+	if json.Unmarshal([]byte(recorded), &a) == nil && json.Unmarshal([]byte(actual), &b) == nil {
+		for _, e := range DiffJSON(a, b, rules) {
+			// A field ignored since the snapshot was recorded is not a change
+			if e.Old == `"`+ignoredValue+`"` || e.New == `"`+ignoredValue+`"` {
+				continue
+			}
+			lines = append(lines, e.String())
+		}
+		if len(lines) == 0 {
+			return SnapshotMatched, nil
+		}
+	} else {
+		for _, line := range diffLines(strings.Split(recorded, "\n"), strings.Split(actual, "\n")) {
+			if !strings.HasPrefix(line, "  ") {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return "", SnapshotError{Path: path, Lines: lines}
+}
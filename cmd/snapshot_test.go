@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSnapshot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api.http")
+	path := SnapshotPath(file, "Get user", 0)
+	if other := SnapshotPath(file, "get-user", 0); other == path {
+		t.Fatalf("both names have the snapshot %s", path)
+	}
+
+	// A file without snapshots is not checked
+	if state, err := CheckSnapshot(path, `{"id": 1}`, nil, false); state != "" || err != nil {
+		t.Errorf("no snapshots: state %q, error %v", state, err)
+	}
+
+	if state, err := CheckSnapshot(path, `{"id": 1, "at": "now"}`, []string{"at"}, true); state != SnapshotWritten || err != nil {
+		t.Fatalf("update: state %q, error %v", state, err)
+	}
+	if state, err := CheckSnapshot(path, `{"at": "later", "id": 1}`, []string{"at"}, false); state != SnapshotMatched || err != nil {
+		t.Errorf("same body: state %q, error %v", state, err)
+	}
+	var snapErr SnapshotError
+	if _, err := CheckSnapshot(path, `{"id": 2}`, []string{"at"}, false); !errors.As(err, &snapErr) {
+		t.Errorf("changed body: error %v", err)
+	}
+
+	// Once the file has snapshots, a request without one fails
+	if state, err := CheckSnapshot(SnapshotPath(file, "get-user", 0), `{}`, nil, false); state != SnapshotMissing || err == nil {
+		t.Errorf("missing snapshot: state %q, error %v", state, err)
+	}
+}
+
+func TestMigrateSnapshot(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "api.http")
+	path := SnapshotPath(file, "Get user", 2)
+	legacy := filepath.Join(filepath.Dir(path), "get-user.2.snap")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	migrateSnapshot(file, "Get user", 2)
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the snapshot was not renamed: %v", err)
+	}
+	if _, err := os.Stat(legacy); err == nil {
+		t.Error("the old snapshot is still there")
+	}
+}
@@ -28,6 +28,7 @@ var (
 	patchMethodColor  = lipgloss.Color("#ff6f00ff")
 	deleteMethodColor = lipgloss.Color("#ff0000ff")
 	infoMethodColor   = lipgloss.Color("#42d6fbff")
+	grpcMethodColor   = lipgloss.Color("#00c9a7ff")
 
 	otherMethodStyle  = boldStyle.Foreground(blackColor).Background(otherMethodColor).Padding(0, 1)
 	getMethodStyle    = boldStyle.Foreground(blackColor).Background(getMethodColor).Padding(0, 1)
@@ -36,6 +37,7 @@ var (
 	patchMethodStyle  = boldStyle.Foreground(blackColor).Background(patchMethodColor).Padding(0, 1)
 	deleteMethodStyle = boldStyle.Foreground(blackColor).Background(deleteMethodColor).Padding(0, 1)
 	infoMethodStyle   = boldStyle.Foreground(blackColor).Background(infoMethodColor).Padding(0, 1)
+	grpcMethodStyle   = boldStyle.Foreground(blackColor).Background(grpcMethodColor).Padding(0, 1)
 
 	otherMethodInactiveColor  = lipgloss.Color("#790877ff")
 	getMethodInactiveColor    = lipgloss.Color("#128308ff")
@@ -44,6 +46,7 @@ var (
 	patchMethodInactiveColor  = lipgloss.Color("#833f0bff")
 	deleteMethodInactiveColor = lipgloss.Color("#900c0cff")
 	infoMethodInactiveColor   = lipgloss.Color("#2c697eff")
+	grpcMethodInactiveColor   = lipgloss.Color("#0b6e5eff")
 
 	otherMethodInactiveStyle  = boldStyle.Foreground(blackColor).Background(otherMethodInactiveColor).Padding(0, 1)
 	getMethodInactiveStyle    = boldStyle.Foreground(blackColor).Background(getMethodInactiveColor).Padding(0, 1)
@@ -52,12 +55,17 @@ var (
 	patchMethodInactiveStyle  = boldStyle.Foreground(blackColor).Background(patchMethodInactiveColor).Padding(0, 1)
 	deleteMethodInactiveStyle = boldStyle.Foreground(blackColor).Background(deleteMethodInactiveColor).Padding(0, 1)
 	infoMethodInactiveStyle   = boldStyle.Foreground(blackColor).Background(infoMethodInactiveColor).Padding(0, 1)
+	grpcMethodInactiveStyle   = boldStyle.Foreground(blackColor).Background(grpcMethodInactiveColor).Padding(0, 1)
 )
 
 var (
 	NormalTitleStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#777777", Dark: "#777777"})
 
+	treeNodeStyle = lipgloss.NewStyle().
+			Foreground(indigo).
+			Bold(true)
+
 	selectedTitle = lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder(), false, false, false, true).
 			BorderForeground(white).
@@ -151,3 +159,59 @@ func (m env) appTopLabel(text string) string {
 func (m env) appBottomLabel(text string) string {
 	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
 }
+
+func (m palette) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m palette) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m benchScreen) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m benchScreen) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m runnerScreen) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m runnerScreen) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m mockView) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m mockView) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m listenView) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m listenView) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m diffScreen) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m diffScreen) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m passphraseScreen) appTopLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("####  "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
+
+func (m passphraseScreen) appBottomLabel(text string) string {
+	return lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.styles.HeaderText.Render("<--- "+text), lipgloss.WithWhitespaceChars("|"), lipgloss.WithWhitespaceForeground(indigo))
+}
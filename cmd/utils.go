@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"encoding/json"
-	"regexp"
-	"strings"
 
 	"github.com/TylerBrock/colorjson"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -13,6 +11,9 @@ import (
 type saveMsg struct {
 	success bool
 	message string
+	stamps  map[string]fileStamp // files as written by the save
+	// conflicts are the files changed on disk that stopped the save
+	conflicts []string
 }
 
 func max(a, b int) int {
@@ -104,10 +105,8 @@ func createHeaders() string {
 }
 
 func replacePlaceholders(url string, variables map[string]string) string {
-	re := regexp.MustCompile(`{{(.*?)}}`)
-	return re.ReplaceAllStringFunc(url, func(match string) string {
-		key := strings.Trim(match, "{}") // Extract key name
-		if value, exists := variables[key]; exists {
+	return placeholderPattern.ReplaceAllStringFunc(url, func(match string) string {
+		if value, exists := lookupVariable(placeholderName(match), variables); exists {
 			return value
 		}
 		return match // Keep original placeholder if key not found
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sources of a variable, by precedence: the variables of a request win over
+// the ones of its file, which win over the environment, then the .env files
+// when dotenvVariables is set, then the process environment
+const (
+	SourceRequest     = "request"
+	SourceFile        = "file"
+	SourceEnvironment = "environment"
+	SourceDotenv      = "dotenv"
+	SourceOS          = "os"
+	SourceSecret      = "secret" // {{$secret name}}, read from the secret store
+)
+
+// Variable is the value of a {{name}} and the source it comes from
+type Variable struct {
+	Name   string
+	Value  string
+	Source string // empty when the variable is not defined
+}
+
+// variableLine is a "@name = value" declaration, the spaces are optional
+var variableLine = regexp.MustCompile(`^@([A-Za-z_][\w.-]*)\s*=\s*(.*)$`)
+
+// variableName is the name of a variable
+var variableName = regexp.MustCompile(`^[A-Za-z_][\w.-]*$`)
+
+// placeholderPattern is a {{name}} reference
+var placeholderPattern = regexp.MustCompile(`{{(.*?)}}`)
+
+// processEnvPrefix reads a variable of the process environment explicitly,
+// as in {{$processEnv HOME}}
+const processEnvPrefix = "$processEnv "
+
+func parseVariableLine(line string) (string, string, bool) {
+	match := variableLine.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], strings.TrimSpace(match[2]), true
+}
+
+// placeholderName is the name of a {{name}} reference
+func placeholderName(match string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(match, "{{"), "}}"))
+}
+
+// lookupVariable finds a variable in vars, then in the process environment.
+// {{$secret name}} reads the secret store.
+func lookupVariable(name string, vars map[string]string) (string, bool) {
+	if value, ok := vars[name]; ok {
+		return value, true
+	}
+	if strings.HasPrefix(name, secretPrefix) {
+		return lookupSecret(strings.TrimSpace(strings.TrimPrefix(name, secretPrefix)))
+	}
+	if strings.HasPrefix(name, processEnvPrefix) {
+		name = strings.TrimSpace(strings.TrimPrefix(name, processEnvPrefix))
+	}
+	return os.LookupEnv(name)
+}
+
+// expandVariables resolves the {{references}} of variables to other
+// variables. References to undefined variables and cycles are left as
+// written.
+func expandVariables(vars map[string]string) map[string]string {
+	expanded := make(map[string]string, len(vars))
+	resolving := map[string]bool{}
+	var resolve func(name string) string
+	resolve = func(name string) string {
+		if value, ok := expanded[name]; ok {
+			return value
+		}
+		resolving[name] = true
+		value := placeholderPattern.ReplaceAllStringFunc(vars[name], func(match string) string {
+			ref := placeholderName(match)
+			if _, ok := vars[ref]; ok && !resolving[ref] {
+				return resolve(ref)
+			}
+			if value, ok := lookupVariable(ref, nil); ok && ref != name {
+				return value
+			}
+			return match
+		})
+		delete(resolving, name)
+		expanded[name] = value
+		return value
+	}
+	for name := range vars {
+		resolve(name)
+	}
+	return expanded
+}
+
+// variableLayer is the variables of one source
+type variableLayer struct {
+	source string
+	values map[string]string
+}
+
+// variableLayers returns the sources of the variables of a .http file, from
+// the lowest precedence to the highest. The .env values are always there as
+// {{$dotenv NAME}}, and as {{NAME}} when the file sets dotenvVariables.
+func variableLayers(httpFile, envName string) ([]variableLayer, error) {
+	globals := LoadGlobalVarsFromHTTPFile(httpFile)
+	dotenv, err := LoadDotenv(httpFile, envName)
+	if err != nil {
+		return nil, err
+	}
+	explicit := make(map[string]string, len(dotenv))
+	for k, v := range dotenv {
+		explicit[dotenvPrefix+k] = v
+	}
+	layers := []variableLayer{{source: SourceDotenv, values: explicit}}
+	if enabled, _ := strconv.ParseBool(globals[dotenvVariablesVar]); enabled {
+		layers = append(layers, variableLayer{source: SourceDotenv, values: dotenv})
+	}
+	vars, err := LoadEnvironment(httpFile, envName)
+	if err != nil {
+		return nil, err
+	}
+	return append(layers,
+		variableLayer{source: SourceEnvironment, values: vars},
+		variableLayer{source: SourceFile, values: globals},
+	), nil
+}
+
+// ScopedVariables returns the variables of a .http file with their
+// sources: the selected environment, overridden by the file variables. The
+// values are as written, see ResolveVariables for their expansion.
+func ScopedVariables(httpFile, envName string) (map[string]Variable, error) {
+	scope := map[string]Variable{}
+	layers, err := variableLayers(httpFile, envName)
+	if err != nil {
+		return scope, err
+	}
+	for _, layer := range layers {
+		for k, v := range layer.values {
+			scope[k] = Variable{Name: k, Value: v, Source: layer.source}
+		}
+	}
+	return scope, nil
+}
+
+// VariableTrace tells where a variable of a .http file comes from, and the
+// sources it overrides
+type VariableTrace struct {
+	Variable
+	Raw        string   // the value as written, before its {{references}} are expanded
+	Overridden []string // lower sources that define it too, the closest first
+}
+
+// TraceVariables lists the variables of a .http file sorted by name, with
+// their expanded value, source and the sources they override
+func TraceVariables(httpFile, envName string) ([]VariableTrace, error) {
+	layers, err := variableLayers(httpFile, envName)
+	if err != nil {
+		return nil, err
+	}
+	traces := map[string]*VariableTrace{}
+	for _, layer := range layers {
+		for k, v := range layer.values {
+			t, ok := traces[k]
+			if !ok {
+				t = &VariableTrace{Variable: Variable{Name: k}}
+				if _, ok := os.LookupEnv(k); ok {
+					t.Overridden = []string{SourceOS}
+				}
+				traces[k] = t
+			} else {
+				t.Overridden = append([]string{t.Source}, t.Overridden...)
+			}
+			t.Source, t.Raw = layer.source, v
+		}
+	}
+	raw := make(map[string]string, len(traces))
+	for k, t := range traces {
+		raw[k] = t.Raw
+	}
+	values := expandVariables(raw)
+	list := make([]VariableTrace, 0, len(traces))
+	for _, k := range sortedKeys(raw) {
+		t := *traces[k]
+		t.Value = values[k]
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+func scopeValues(scope map[string]Variable) map[string]string {
+	values := make(map[string]string, len(scope))
+	for k, v := range scope {
+		values[k] = v.Value
+	}
+	return values
+}
+
+// RequestVariables layers the variables of a request over vars, and
+// expands the references between them
+func RequestVariables(req HTTPRequest, vars map[string]string) map[string]string {
+	merged := make(map[string]string, len(vars)+len(req.Vars))
+	for k, v := range vars {
+		merged[k] = v
+	}
+	for k, v := range req.Vars {
+		merged[k] = v
+	}
+	return expandVariables(merged)
+}
+
+// ReferencedVariables lists the {{variables}} used by a request, in order,
+// with the value they expand to and the source they come from
+func ReferencedVariables(req HTTPRequest, scope map[string]Variable) []Variable {
+	layered := make(map[string]Variable, len(scope)+len(req.Vars))
+	for k, v := range scope {
+		layered[k] = v
+	}
+	for k, v := range req.Vars {
+		layered[k] = Variable{Name: k, Value: v, Source: SourceRequest}
+	}
+	values := expandVariables(scopeValues(layered))
+
+	var refs []Variable
+	seen := map[string]bool{}
+	for _, text := range []string{req.URL, req.Headers, req.Body} {
+		for _, match := range placeholderPattern.FindAllString(text, -1) {
+			name := placeholderName(match)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			v := Variable{Name: name}
+			if defined, ok := layered[name]; ok {
+				v.Value, v.Source = values[name], defined.Source
+			} else if value, ok := lookupVariable(name, nil); ok {
+				v.Value, v.Source = value, SourceOS
+				if strings.HasPrefix(name, secretPrefix) {
+					v.Source = SourceSecret
+				}
+			}
+			refs = append(refs, v)
+		}
+	}
+	return refs
+}
+
+// UndefinedVariables lists the {{variables}} of a request that neither the
+// request, vars nor the process environment define, including the ones
+// referenced by the values of other variables, then the path params with
+// no value, as written in the URL
+func UndefinedVariables(req HTTPRequest, vars map[string]string) []string {
+	req = substituteVariables(req, RequestVariables(req, vars))
+	var undefined []string
+	seen := map[string]bool{}
+	for _, text := range []string{req.URL, req.Headers, req.Body} {
+		for _, match := range placeholderPattern.FindAllString(text, -1) {
+			name := placeholderName(match)
+			if !seen[name] {
+				seen[name] = true
+				undefined = append(undefined, name)
+			}
+		}
+	}
+	return append(undefined, unresolvedPathParams(req.URL)...)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	variableNameStyle      = lipgloss.NewStyle().Foreground(yellow)
+	resolvedVariableStyle  = lipgloss.NewStyle().Foreground(green)
+	variableSourceStyle    = lipgloss.NewStyle().Faint(true)
+	undefinedVariableStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// editedRequest is the request in the fields, with the variables declared
+// in its section of the file
+func (m Model) editedRequest() HTTPRequest {
+	req := HTTPRequest{
+		Method:  strings.ToUpper(strings.TrimSpace(m.methodField.Value())),
+		URL:     strings.TrimSpace(m.urlField.Value()),
+		Headers: strings.TrimSpace(m.headersArea.Value()),
+		Body:    m.bodyArea.Value(),
+	}
+	if item, ok := m.requestsList.SelectedItem().(request); ok {
+		req.Name = item.title
+		req.Vars = item.vars
+		req.Directives = item.directives
+	}
+	return req
+}
+
+// variablesFor returns the variables of the request in the fields with the
+// environment envName
+func (m Model) variablesFor(envName string) map[string]string {
+	vars, _ := ResolveVariables(m.filepath, envName)
+	return RequestVariables(m.editedRequest(), vars)
+}
+
+// variablesView previews what each {{variable}} of the request expands to
+func (m Model) variablesView() string {
+	if m.scopeErr != nil {
+		return undefinedVariableStyle.Render(m.scopeErr.Error())
+	}
+	refs := m.references
+
+	var sb strings.Builder
+	env := m.environment
+	if env == "" {
+		env = "none"
+	}
+	sb.WriteString(boldStyle.Render("Environment: ") + env + "\n\n")
+	if len(refs) == 0 {
+		sb.WriteString(variableSourceStyle.Render("The request uses no {{variables}}") + "\n")
+	}
+	nameWidth := 0
+	for _, v := range refs {
+		nameWidth = max(nameWidth, len(v.Name)+4)
+	}
+	for _, v := range refs {
+		name := variableNameStyle.Render(fmt.Sprintf("%-*s", nameWidth, "{{"+v.Name+"}}"))
+		if v.Source == "" {
+			sb.WriteString(name + "  " + undefinedVariableStyle.Render("undefined") + "\n")
+			continue
+		}
+		value := ansi.Truncate(strings.ReplaceAll(MaskSecrets(v.Value), "\n", " "), max(m.tabContentWidth-nameWidth-16, 8), "…")
+		sb.WriteString(name + "  " + value + "  " + variableSourceStyle.Render(v.Source) + "\n")
+	}
+	sb.WriteString("\n" + variableSourceStyle.Render("request > file > environment > os, declare request variables with @name = value below the ### line"))
+	return sb.String()
+}
+
+// renderedPlaceholder is a {{name}} of a rendered editor, the ones split by
+// the cursor or a style are left alone
+var renderedPlaceholder = regexp.MustCompile("{{[^{}\x1b]*}}")
+
+// sgrSequence is an ANSI style sequence
+var sgrSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// highlightVariables colours the {{variables}} of a rendered editor by
+// whether defined says they resolve. The style of the editor is restored
+// after each of them.
+func highlightVariables(view string, defined func(name string) bool) string {
+	var sb strings.Builder
+	last, style := 0, ""
+	for _, loc := range renderedPlaceholder.FindAllStringIndex(view, -1) {
+		before := view[last:loc[0]]
+		if codes := sgrSequence.FindAllString(before, -1); len(codes) > 0 {
+			style = codes[len(codes)-1]
+		}
+		token := view[loc[0]:loc[1]]
+		tokenStyle := resolvedVariableStyle
+		if !defined(placeholderName(token)) {
+			tokenStyle = undefinedVariableStyle
+		}
+		sb.WriteString(before + tokenStyle.Render(token) + style)
+		last = loc[1]
+	}
+	sb.WriteString(view[last:])
+	return sb.String()
+}
+
+// refreshScope reads the variables of the file and the environment again,
+// they can run secret-tool or decrypt the secrets file so View never does it
+func (m *Model) refreshScope() {
+	m.fileScope, m.scopeErr = ScopedVariables(m.filepath, m.environment)
+	m.scopeRequest()
+}
+
+// scopeRequest keeps the variables the request in the fields can use, by
+// name, with their source, and the ones it references
+func (m *Model) scopeRequest() {
+	scope := maps.Clone(m.fileScope)
+	if scope == nil {
+		scope = map[string]Variable{}
+	}
+	m.references = ReferencedVariables(m.editedRequest(), scope)
+	for _, v := range m.references {
+		if v.Source != "" {
+			scope[v.Name] = v
+		}
+	}
+	m.scope = scope
+}
+
+// highlightEditor highlights the {{variables}} of the rendered editor view
+func (m Model) highlightEditor(view string, scope map[string]Variable) string {
+	return highlightVariables(view, func(name string) bool {
+		_, ok := scope[name]
+		return ok
+	})
+}
+
+// cursorVariable is the {{variable}} under the cursor of the focused editor
+func (m Model) cursorVariable() (string, bool) {
+	var line string
+	var col int
+	switch {
+	case m.focused == urlFieldPanel:
+		line, col = m.urlField.Value(), m.urlField.Position()
+	case m.focused == tabContentPanel && (m.activeTab == bodyTab || m.activeTab == headersTab):
+		area := m.bodyArea
+		if m.activeTab == headersTab {
+			area = m.headersArea
+		}
+		lines := strings.Split(area.Value(), "\n")
+		if area.Line() >= len(lines) {
+			return "", false
+		}
+		info := area.LineInfo()
+		line, col = lines[area.Line()], info.StartColumn+info.ColumnOffset
+	default:
+		return "", false
+	}
+	runes := []rune(line)
+	offset := len(string(runes[:min(col, len(runes))]))
+	for _, loc := range placeholderPattern.FindAllStringIndex(line, -1) {
+		if loc[0] <= offset && offset <= loc[1] {
+			return placeholderName(line[loc[0]:loc[1]]), true
+		}
+	}
+	return "", false
+}
+
+// variableHint tells what the {{variable}} under the cursor resolves to,
+// "" when the cursor is not on one
+func (m Model) variableHint(scope map[string]Variable) string {
+	name, ok := m.cursorVariable()
+	if !ok {
+		return ""
+	}
+	hint := fmt.Sprintf("{{%s}} is undefined", name)
+	if v, ok := scope[name]; ok {
+		hint = fmt.Sprintf("{{%s}} = %s (%s)", name, strings.ReplaceAll(MaskSecrets(v.Value), "\n", " "), v.Source)
+	}
+	return ansi.Truncate(hint, max(m.width-4, 8), "…")
+}
+
+// undefinedVariables lists the {{variables}} of the request in the fields
+// that resolve to nothing
+func (m Model) undefinedVariables() []string {
+	vars, _ := ResolveVariables(m.filepath, m.environment)
+	return UndefinedVariables(m.editedRequest(), vars)
+}
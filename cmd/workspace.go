@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+const (
+	folderNode = iota
+	fileNode
+)
+
+// treeNode is a folder or a .http file shown in the requests list when
+// postbear is opened on a directory. Collapsed nodes keep their children
+// in hidden so the list stays the only copy of the requests.
+type treeNode struct {
+	kind      int
+	name      string
+	path      string
+	depth     int
+	collapsed bool
+	hidden    []list.Item
+}
+
+func (n treeNode) FilterValue() string { return n.name }
+
+// IsWorkspace reports whether path is a directory to be opened as a workspace
+func IsWorkspace(path string) bool {
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(httpFilePath(path))
+	return err == nil && info.IsDir()
+}
+
+func isHTTPFile(path string) bool {
+	return strings.HasSuffix(path, ".http") || strings.HasSuffix(path, ".rest")
+}
+
+// DiscoverHTTPFiles returns every .http and .rest file under root, skipping
+// hidden directories and dependency folders
+func DiscoverHTTPFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(httpFilePath(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != httpFilePath(root) && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isHTTPFile(path) {
+			rel, err := filepath.Rel(httpFilePath(root), path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.Join(root, rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// workspaceItems builds the folder tree for the requests list
+func workspaceItems(root string, files []string) []list.Item {
+	var items []list.Item
+	var openFolders []string
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+		dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+		if dirs[0] == "." {
+			dirs = nil
+		}
+
+		// Keep the folders shared with the previous file, open the new ones
+		common := 0
+		for common < len(dirs) && common < len(openFolders) && dirs[common] == openFolders[common] {
+			common++
+		}
+		openFolders = openFolders[:common]
+		for i := common; i < len(dirs); i++ {
+			openFolders = append(openFolders, dirs[i])
+			items = append(items, treeNode{
+				kind:  folderNode,
+				name:  dirs[i],
+				path:  filepath.Join(root, filepath.Join(dirs[:i+1]...)),
+				depth: i,
+			})
+		}
+
+		items = append(items, treeNode{
+			kind:  fileNode,
+			name:  filepath.Base(file),
+			path:  file,
+			depth: len(dirs),
+		})
+		data, err := LoadHTTPFile(file)
+		if err != nil {
+			continue
+		}
+		for _, req := range data.Requests {
+			item := requestFromHTTP(req)
+			item.file = file
+			item.depth = len(dirs) + 1
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func itemDepth(item list.Item) int {
+	switch i := item.(type) {
+	case treeNode:
+		return i.depth
+	case request:
+		return i.depth
+	}
+	return 0
+}
+
+// toggleNode collapses or expands the tree node at idx
+func toggleNode(l *list.Model, idx int) {
+	node, ok := l.Items()[idx].(treeNode)
+	if !ok {
+		return
+	}
+	if node.collapsed {
+		node.collapsed = false
+		for i, child := range node.hidden {
+			l.InsertItem(idx+1+i, child)
+		}
+		node.hidden = nil
+	} else {
+		node.collapsed = true
+		for idx+1 < len(l.Items()) && itemDepth(l.Items()[idx+1]) > node.depth {
+			node.hidden = append(node.hidden, l.Items()[idx+1])
+			l.RemoveItem(idx + 1)
+		}
+	}
+	l.SetItem(idx, node)
+}
+
+// allRequests flattens the list, including requests inside collapsed nodes
+func allRequests(items []list.Item) []request {
+	var requests []request
+	for _, item := range items {
+		switch i := item.(type) {
+		case request:
+			requests = append(requests, i)
+		case treeNode:
+			requests = append(requests, allRequests(i.hidden)...)
+		}
+	}
+	return requests
+}
+
+// workspaceFiles returns the path of every file node in the list
+func workspaceFiles(items []list.Item) []string {
+	var files []string
+	for _, item := range items {
+		if node, ok := item.(treeNode); ok {
+			if node.kind == fileNode {
+				files = append(files, node.path)
+			}
+			files = append(files, workspaceFiles(node.hidden)...)
+		}
+	}
+	return files
+}